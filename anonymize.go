@@ -0,0 +1,270 @@
+package logstf
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/leighmacdonald/steamid"
+	"io"
+	"strings"
+)
+
+const (
+	// RedactedChat replaces the message of say and say_team lines when ScrubChat is enabled
+	RedactedChat = "[redacted]"
+	// RedactedArgs replaces the arguments of rcon commands which may target a player
+	RedactedArgs = "[redacted]"
+)
+
+// rconCommandPrefix follows the address of rcon lines
+const rconCommandPrefix = `": command "`
+
+// Anonymizer rewrites log lines so they can be shared without identifying the players. Every
+// SteamID is replaced with a pseudonymous Steam3 id and the name with an alias derived from it,
+// the connection address is dropped and chat can optionally be scrubbed. Everything else is left
+// untouched so the output applies to the same stats as the input.
+//
+// Pseudonyms are derived from a secret key so they are stable between logs anonymized with the
+// same key but cannot be reversed by hashing known ids. Bots and the console are left as is.
+type Anonymizer struct {
+	ScrubChat bool
+	key       []byte
+	ids       map[steamid.SID64]uint32
+	used      map[uint32]bool
+}
+
+// NewAnonymizer creates an Anonymizer using key to derive the pseudonyms. A random key is used
+// when key is empty, in which case the pseudonyms are only stable for this Anonymizer.
+func NewAnonymizer(key []byte) *Anonymizer {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			panic(err)
+		}
+	}
+	return &Anonymizer{key: key, ids: map[steamid.SID64]uint32{}, used: map[uint32]bool{}}
+}
+
+// pseudonym returns the account id used in place of the SteamID. Collisions are resolved by
+// probing so that two players are never merged.
+func (a *Anonymizer) pseudonym(sid steamid.SID64) uint32 {
+	if id, found := a.ids[sid]; found {
+		return id
+	}
+	mac := hmac.New(sha256.New, a.key)
+	_ = binary.Write(mac, binary.BigEndian, uint64(sid))
+	id := binary.BigEndian.Uint32(mac.Sum(nil))
+	for id == 0 || a.used[id] {
+		id++
+	}
+	a.ids[sid] = id
+	a.used[id] = true
+	return id
+}
+
+// Line returns the anonymized version of a single log line
+func (a *Anonymizer) Line(line string) string {
+	line = strings.TrimRight(line, "\r\n")
+	// Keep the "L 07/10/2019 - 23:28:01: " prefix as is
+	start := 0
+	if strings.HasPrefix(line, "L ") {
+		if i := strings.Index(line, ": "); i >= 0 {
+			start = i + 2
+		}
+	}
+	var b strings.Builder
+	b.Grow(len(line))
+	b.WriteString(line[:start])
+	body := line[start:]
+	if strings.HasPrefix(body, `rcon from "`) {
+		if end := strings.Index(body, rconCommandPrefix); end >= 0 && len(body) > end+len(rconCommandPrefix) {
+			cmd := strings.TrimSuffix(body[end+len(rconCommandPrefix):], `"`)
+			body = `rcon from "` + rconCommandPrefix + scrubCommand(cmd) + `"`
+		} else if end := strings.Index(body, `":`); end >= 0 {
+			body = `rcon from ""` + body[end+1:]
+		}
+		b.WriteString(body)
+		return b.String()
+	}
+	// Kick and Banid lines lead with the action before the player
+	for _, action := range []string{"Kick: ", "Banid: "} {
+		if strings.HasPrefix(body, action) {
+			b.WriteString(action)
+			body = body[len(action):]
+			break
+		}
+	}
+	tok, ref, ok := scanPlayerToken(body)
+	if !ok {
+		// World and Team lines only reference players within their properties
+		b.WriteString(a.properties(body))
+		return b.String()
+	}
+	newTok, alias := a.token(tok, ref)
+	b.WriteString(newTok)
+	rest := body[len(tok):]
+	switch {
+	case strings.HasPrefix(rest, " connected, address "):
+		b.WriteString(` connected, address ""`)
+	case strings.HasPrefix(rest, " say "), strings.HasPrefix(rest, " say_team "):
+		// Chat is never scanned for tokens, players can type anything
+		if a.ScrubChat {
+			verb := rest[:strings.IndexByte(rest[1:], ' ')+2]
+			b.WriteString(verb + `"` + RedactedChat + `"`)
+		} else {
+			b.WriteString(rest)
+		}
+	case strings.HasPrefix(rest, ` changed name to "`):
+		// The new name is as identifying as the old one
+		b.WriteString(` changed name to "` + alias + `"`)
+	default:
+		// The second player of "killed", "triggered ... against" and kick or ban lines
+		for _, verb := range []string{" killed ", `" against `, " by "} {
+			i := strings.Index(rest, verb)
+			if i < 0 {
+				continue
+			}
+			i += len(verb)
+			if tok2, ref2, ok := scanPlayerToken(rest[i:]); ok {
+				newTok2, _ := a.token(tok2, ref2)
+				b.WriteString(rest[:i] + newTok2)
+				rest = rest[i+len(tok2):]
+				break
+			}
+		}
+		b.WriteString(a.properties(scrubName(rest, ref.Name, alias)))
+	}
+	return b.String()
+}
+
+// scrubName replaces the name within disconnect reasons and kick messages, they often include it
+// eg. "rad timed out"
+func scrubName(s string, name string, alias string) string {
+	if name == "" || name == alias {
+		return s
+	}
+	for _, prop := range []string{`(reason "`, `(message "`} {
+		if i := strings.Index(s, prop); i >= 0 {
+			return s[:i] + strings.Replace(s[i:], name, alias, -1)
+		}
+	}
+	return s
+}
+
+// scrubCommand redacts the arguments of rcon commands, kick "rad" or banid 5 [U:1:57823119] would
+// otherwise leak the player. Commands used for the server details in the summary are kept.
+func scrubCommand(command string) string {
+	cmds := strings.Split(command, ";")
+	for i, cmd := range cmds {
+		fields := strings.Fields(cmd)
+		if len(fields) < 2 || safeCommand(fields[0]) {
+			continue
+		}
+		cmds[i] = cmd[:strings.Index(cmd, fields[0])+len(fields[0])] + " " + RedactedArgs
+	}
+	return strings.Join(cmds, ";")
+}
+
+// safeCommand returns true for commands whose arguments never identify a player
+func safeCommand(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "exec", "changelevel", cvarHostname:
+		return true
+	}
+	return strings.HasPrefix(name, "mp_")
+}
+
+// properties rewrites the player tokens used as property values such as (player1 "...") in
+// the properties at the end of s
+func (a *Anonymizer) properties(s string) string {
+	i, _ := findPropertiesIndex(s)
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.WriteString(s[:i])
+	props := s[i:]
+	for {
+		trimmed := strings.TrimLeft(props, " ")
+		b.WriteString(props[:len(props)-len(trimmed)])
+		if trimmed == "" {
+			return b.String()
+		}
+		_, value, rest, ok := scanProperty(trimmed)
+		if !ok {
+			b.WriteString(trimmed)
+			return b.String()
+		}
+		prop := trimmed[:len(trimmed)-len(rest)]
+		if ref, err := ParsePlayerRef(value); err == nil {
+			valueStart := len(prop) - len(value) - 2
+			newTok, _ := a.token(`"`+value+`"`, ref)
+			prop = prop[:valueStart] + newTok + ")"
+		}
+		b.WriteString(prop)
+		props = rest
+	}
+}
+
+// token returns the anonymized replacement for a quoted player token along with the alias
+func (a *Anonymizer) token(tok string, ref PlayerRef) (string, string) {
+	if ref.IsBot || ref.IsConsole {
+		return tok, ref.Name
+	}
+	inner := tok[1 : len(tok)-1]
+	teamStart := strings.LastIndexByte(inner, '<')
+	sidStart := strings.LastIndexByte(inner[:teamStart], '<')
+	pidStart := strings.LastIndexByte(inner[:sidStart], '<')
+	id := a.pseudonym(ref.SteamID)
+	alias := fmt.Sprintf("player-%08x", id)
+	return fmt.Sprintf(`"%s%s<[U:1:%d]>%s"`, alias, inner[pidStart:sidStart], id, inner[teamStart:]), alias
+}
+
+// scanPlayerToken reads a quoted player token from the start of s, returning the token including
+// its quotes. As with the lexer each candidate end is tried so names may contain `>"`.
+func scanPlayerToken(s string) (string, PlayerRef, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", PlayerRef{}, false
+	}
+	offset := 1
+	for {
+		end := strings.Index(s[offset:], `>"`)
+		if end < 0 {
+			return "", PlayerRef{}, false
+		}
+		end += offset
+		next := end + 2
+		if next == len(s) || s[next] == ' ' {
+			if ref, err := ParsePlayerRef(s[1 : end+1]); err == nil {
+				return s[:next], ref, true
+			}
+		}
+		offset = end + 1
+	}
+}
+
+// Anonymize copies all of the lines from r to w through Line
+func (a *Anonymizer) Anonymize(ctx context.Context, w io.Writer, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	bw := bufio.NewWriter(w)
+	for scanner.Scan() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if _, err := bw.WriteString(a.Line(scanner.Text()) + "\n"); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,144 @@
+package logstf
+
+import (
+	"bytes"
+	"context"
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizerLine(t *testing.T) {
+	a := NewAnonymizer([]byte("secret"))
+	line := a.Line(`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`)
+	assert.NotContains(t, line, "rad")
+	assert.NotContains(t, line, "57823119")
+	assert.NotContains(t, line, "66656848")
+	ev, err := ParseEvent(line)
+	require.NoError(t, err)
+	dmg := ev.(*DamageEvent)
+	assert.Equal(t, int64(90), dmg.Damage)
+	assert.Equal(t, RED, dmg.Player.Team)
+	assert.Equal(t, 6, dmg.Player.PID)
+
+	// Stable for the same key and different for another
+	assert.Equal(t, line, NewAnonymizer([]byte("secret")).Line(`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`))
+	assert.NotEqual(t, line, NewAnonymizer([]byte("other")).Line(`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`))
+	// Without a key a random one is used rather than a known empty one
+	assert.NotEqual(t, NewAnonymizer(nil).Line(line), NewAnonymizer(nil).Line(line))
+
+	assert.Equal(t, `L 07/10/2019 - 23:15:20: "player-`, a.Line(`L 07/10/2019 - 23:15:20: "rad<6><[U:1:57823119]><>" connected, address "1.2.3.4:51378"`)[:33])
+	assert.True(t, strings.HasSuffix(a.Line(`L 07/10/2019 - 23:15:20: "rad<6><[U:1:57823119]><>" connected, address "1.2.3.4:51378"`), `connected, address ""`))
+	assert.Equal(t, `L 07/11/2019 - 00:50:12: rcon from "": command "status"`, a.Line(`L 07/11/2019 - 00:50:12: rcon from "1.2.3.4:5": command "status"`))
+	assert.Equal(t, `L 07/11/2019 - 00:50:12: rcon from "": command "kick [redacted]"`, a.Line(`L 07/11/2019 - 00:50:12: rcon from "1.2.3.4:5": command "kick "rad""`))
+	assert.Equal(t, `L 07/11/2019 - 00:50:12: rcon from "": command "banid [redacted]; exec etf2l_6v6_5cp; sm_ban [redacted]"`,
+		a.Line(`L 07/11/2019 - 00:50:12: rcon from "1.2.3.4:5": command "banid 5 [U:1:57823119]; exec etf2l_6v6_5cp; sm_ban STEAM_0:1:28911559 0 "rad""`))
+	assert.NotContains(t, a.Line(`L 07/10/2019 - 23:31:19: "z/<14><[U:1:66656848]><Blue>" disconnected (reason "z/ timed out")`), "z/")
+
+	hostile := a.Line(`L 07/10/2019 - 23:28:00: "a<1><b>" x<6><[U:1:57823119]><Red>" killed "Bot01<3><BOT><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`)
+	assert.NotContains(t, hostile, "a<1><b>")
+	assert.Contains(t, hostile, `killed "Bot01<3><BOT><Blue>" with`)
+	_, err = ParseEvent(hostile)
+	require.NoError(t, err)
+
+	capture := a.Line(`L 07/10/2019 - 23:29:30: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`)
+	assert.NotContains(t, capture, "rad")
+	assert.Contains(t, capture, `(cpname "#Badlands_cap_cp3")`)
+
+	rename := a.Line(`L 07/10/2019 - 23:15:30: "rad<6><[U:1:57823119]><Red>" changed name to "radical"`)
+	assert.NotContains(t, rename, "rad<")
+	assert.NotContains(t, rename, "radical")
+	assert.NotContains(t, rename, "57823119")
+
+	for _, line := range []string{
+		`L 07/10/2019 - 23:40:00: Kick: "rad<6><[U:1:57823119]><>" was kicked by "Console" (message "rad was kicked")`,
+		`L 07/10/2019 - 23:40:00: Banid: "rad<6><[U:1:57823119]><>" was banned "for 5.00 minutes" by "Console"`,
+		`L 07/10/2019 - 23:40:00: Kick: "wonder<7><[U:1:34284979]><Red>" was kicked by "rad<6><[U:1:57823119]><Red>" (message "")`,
+	} {
+		out := a.Line(line)
+		assert.NotContains(t, out, "rad")
+		assert.NotContains(t, out, "wonder")
+		assert.NotContains(t, out, "57823119")
+		assert.NotContains(t, out, "34284979")
+		assert.Equal(t, line[:len("L 07/10/2019 - 23:40:00: Kick: ")], out[:len("L 07/10/2019 - 23:40:00: Kick: ")])
+	}
+
+	// Bots and the console are not people
+	bot := `L 07/10/2019 - 23:28:00: "Console<0><Console><Console>" say "hello"`
+	assert.Equal(t, bot, a.Line(bot))
+
+	say := `L 07/10/2019 - 23:29:26: "wonder<7><[U:1:34284979]><Red>" say_team "rad<6><[U:1:57823119]><Red>"`
+	assert.True(t, strings.HasSuffix(a.Line(say), ` say_team "rad<6><[U:1:57823119]><Red>"`))
+	a.ScrubChat = true
+	assert.True(t, strings.HasSuffix(a.Line(say), ` say_team "[redacted]"`))
+}
+
+func TestAnonymizerSummary(t *testing.T) {
+	a := NewAnonymizer(nil)
+	var out bytes.Buffer
+	require.NoError(t, a.Anonymize(context.Background(), &out, strings.NewReader(strings.Join(testMatchLines, "\n"))))
+	for _, name := range []string{"rad", "wonder", "Graba", "57823119", "34284979", "66656848", "95947321", "1.2.3.4"} {
+		assert.NotContains(t, out.String(), name)
+	}
+	orig := NewSummary()
+	require.NoError(t, orig.ApplyReader(context.Background(), strings.NewReader(strings.Join(testMatchLines, "\n"))))
+	anon := NewSummary()
+	require.NoError(t, anon.ApplyReader(context.Background(), &out))
+
+	anonSid := func(sid steamid.SID64) steamid.SID64 {
+		return steamid.SID64(sid64Base + uint64(a.ids[sid]))
+	}
+	require.Equal(t, len(orig.Players), len(anon.Players))
+	for sid, pOrig := range orig.Players {
+		for _, kills := range [][]Kill{pOrig.Kills, pOrig.Deaths} {
+			for i := range kills {
+				kills[i].Victim = anonSid(kills[i].Victim)
+			}
+		}
+		for i := range pOrig.Extinguishes {
+			pOrig.Extinguishes[i].Target = anonSid(pOrig.Extinguishes[i].Target)
+		}
+		byMedic := make(map[steamid.SID64]int64)
+		for medicSid, amount := range pOrig.HealsByMedic {
+			byMedic[anonSid(medicSid)] = amount
+		}
+		pOrig.HealsByMedic = byMedic
+		pAnon, found := anon.Players[anonSid(sid)]
+		require.True(t, found)
+		pOrig.summary, pAnon.summary = nil, nil
+		pOrig.Name, pAnon.Name = "", ""
+		pOrig.SteamId, pAnon.SteamId = 0, 0
+		for i := range pOrig.Sessions {
+			// Disconnect reasons can contain the players name
+			pOrig.Sessions[i].Reason, pAnon.Sessions[i].Reason = "", ""
+		}
+		if pOrig.HealingSum != nil {
+			assert.Equal(t, len(pOrig.HealingSum.Targets), len(pAnon.HealingSum.Targets))
+			pOrig.HealingSum.Targets, pAnon.HealingSum.Targets = nil, nil
+		}
+		assert.Equal(t, pOrig, pAnon)
+	}
+	for _, r := range orig.Rounds {
+		for i := range r.Events {
+			for _, sid := range []*steamid.SID64{&r.Events[i].SteamId, &r.Events[i].Killer, &r.Events[i].Victim} {
+				if *sid != 0 {
+					*sid = anonSid(*sid)
+				}
+			}
+		}
+		for i := range r.Captures {
+			for j, sid := range r.Captures[i].Cappers {
+				r.Captures[i].Cappers[j] = anonSid(sid)
+			}
+		}
+		for i := range r.Blocks {
+			r.Blocks[i].SteamId = anonSid(r.Blocks[i].SteamId)
+		}
+	}
+	assert.Equal(t, orig.Rounds, anon.Rounds)
+	assert.Equal(t, orig.Teams, anon.Teams)
+	assert.Equal(t, orig.Report.UnhandledCount(), anon.Report.UnhandledCount())
+	assert.Equal(t, len(orig.Messages), len(anon.Messages))
+}
@@ -23,11 +23,12 @@ type TeamStats struct {
 }
 
 type weaponStats struct {
-	Kills  int     `json:"kills"`
-	Dmg    int     `json:"dmg"`
-	AvgDmg float64 `json:"avg_dmg"`
-	Shots  int     `json:"shots"`
-	Hits   int     `json:"hits"`
+	Kills      int     `json:"kills"`
+	Dmg        int     `json:"dmg"`
+	AvgDmg     float64 `json:"avg_dmg"`
+	Shots      int     `json:"shots"`
+	Hits       int     `json:"hits"`
+	damageHits int     // Damage events counted towards AvgDmg
 }
 
 type classKills struct {
@@ -219,6 +220,9 @@ func (a *ApiResponse) Summary() *LogSummary {
 	s := NewSummary()
 	s.Map = a.Info.Map
 	s.MatchName = a.Info.Title
+	s.updateGameMode()
+	s.ScoreRed = a.Teams.Red.Score
+	s.ScoreBlu = a.Teams.Blue.Score
 	s.CreatedOn = time.Unix(a.Info.TotalLength, 0)
 	for sid3, p := range a.Players {
 		player := NewPlayer(s)
@@ -240,7 +244,20 @@ func (a *ApiResponse) Summary() *LogSummary {
 			})
 		}
 		for _, cs := range p.ClassStats {
-			player.AddClass(parsePlayerClass(cs.Type))
+			cls := parsePlayerClass(cs.Type)
+			player.AddClass(cls)
+			*player.Classes[cls] = classStats{
+				Kills:     cs.Kills,
+				Assist:    cs.Assists,
+				Deaths:    cs.Deaths,
+				Damage:    cs.Dmg,
+				TotalTime: time.Duration(cs.TotalTime) * time.Second,
+			}
+			for name, ws := range cs.Weapon {
+				if w := player.weapon(name); w != nil {
+					w.merge(ws)
+				}
+			}
 		}
 		player.AirShots = p.As
 		player.BackStabs = p.Backstabs
@@ -251,9 +268,34 @@ func (a *ApiResponse) Summary() *LogSummary {
 		}
 		player.Captures = p.Cpc
 		player.DamageTaken = p.Dt
+		player.HealsReceived = int64(p.Hr)
 		s.Players[player.SteamId] = player
 	}
-	for _, r := range a.Rounds {
+	for i, r := range a.Rounds {
+		var events []RoundEvent
+		for _, e := range r.Events {
+			// The round start is a unix timestamp and the event time is seconds into the round
+			ev := RoundEvent{
+				Type:      RoundEventType(e.Type),
+				Time:      time.Duration(e.Time) * time.Second,
+				MatchTime: time.Duration(r.StartTime-a.Rounds[0].StartTime+e.Time) * time.Second,
+				CreatedOn: time.Unix(int64(r.StartTime+e.Time), 0).UTC(),
+				Round:     i + 1,
+				Team:      parseTeam(e.Team),
+				Point:     e.Point,
+			}
+			if e.Steamid != "" {
+				ev.SteamId = steam.SID3ToSID64(steam.SID3(e.Steamid))
+			}
+			if e.Killer != "" {
+				ev.Killer = steam.SID3ToSID64(steam.SID3(e.Killer))
+			}
+			if e.Medigun != "" {
+				ev.Medigun = parseMedigun(e.Medigun)
+			}
+			events = append(events, ev)
+		}
+		s.events = append(s.events, events...)
 		s.Rounds = append(s.Rounds, &RoundSummary{
 			Winner:    parseTeam(r.Winner),
 			Length:    time.Duration(r.Length) * time.Second,
@@ -267,6 +309,7 @@ func (a *ApiResponse) Summary() *LogSummary {
 			DamageRed: r.Team.Red.Dmg,
 			DamageBlu: r.Team.Blu.Dmg,
 			MidFight:  parseTeam(r.FirstCap),
+			Events:    events,
 		})
 	}
 	return s
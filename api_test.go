@@ -1,8 +1,12 @@
 package logstf
 
 import (
+	"encoding/json"
+	"github.com/leighmacdonald/steamid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 func TestFetchAPI(t *testing.T) {
@@ -15,9 +19,66 @@ func TestFetchAPI(t *testing.T) {
 }
 
 func TestReadJSON(t *testing.T) {
+	defer func(dir string) { cacheDir = dir }(cacheDir)
+	cacheDir = testBaseDir()
 	js, e := ReadJSON(672005)
-	assert.NoError(t, e)
+	require.NoError(t, e)
 	s := js.Summary()
 	assert.Equal(t, 3, s.ScoreBlu)
 	assert.Equal(t, 2, s.ScoreRed)
 }
+
+// apiRoundsFixture is the rounds section of a logs.tf api response trimmed to a few events
+const apiRoundsFixture = `{"version": 3, "rounds": [
+	{"start_time": 1562800060, "winner": "Red",
+		"team": {"Blue": {"score": 0, "kills": 12, "dmg": 4236, "ubers": 1}, "Red": {"score": 1, "kills": 19, "dmg": 5783, "ubers": 1}},
+		"events": [
+			{"type": "pointcap", "time": 44, "team": "Red", "point": 3},
+			{"type": "charge", "medigun": "medigun", "time": 91, "steamid": "[U:1:95947321]", "team": "Blue"},
+			{"type": "medic_death", "time": 120, "team": "Blue", "steamid": "[U:1:95947321]", "killer": "[U:1:57823119]"},
+			{"type": "round_win", "time": 247, "team": "Red"}
+		],
+		"firstcap": "Red", "length": 247},
+	{"start_time": 1562800337, "winner": "Blue",
+		"team": {"Blue": {"score": 1, "kills": 8, "dmg": 2790, "ubers": 0}, "Red": {"score": 1, "kills": 3, "dmg": 1304, "ubers": 0}},
+		"events": [
+			{"type": "pointcap", "time": 21, "team": "Blue", "point": 3},
+			{"type": "round_win", "time": 95, "team": "Blue"}
+		],
+		"firstcap": "Blue", "length": 95}
+], "info": {"map": "cp_badlands", "date": 1562800450}}`
+
+func TestApiRoundEvents(t *testing.T) {
+	var a ApiResponse
+	require.NoError(t, json.Unmarshal([]byte(apiRoundsFixture), &a))
+	s := a.Summary()
+	require.Equal(t, 2, len(s.Rounds))
+	assert.Equal(t, RED, s.Rounds[0].MidFight)
+	assert.Equal(t, 19, s.Rounds[0].KillsRed)
+
+	death := s.Rounds[0].Events[2]
+	assert.Equal(t, RoundEventMedicDeath, death.Type)
+	assert.Equal(t, 120*time.Second, death.Time)
+	assert.Equal(t, 120*time.Second, death.MatchTime)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 9, 40, 0, time.UTC), death.CreatedOn)
+	assert.Equal(t, steamid.SID64(76561198018088847), death.Killer)
+	assert.Equal(t, 1, death.Round)
+
+	cap2 := s.Rounds[1].Events[0]
+	assert.Equal(t, 21*time.Second, cap2.Time)
+	assert.Equal(t, 298*time.Second, cap2.MatchTime)
+	assert.Equal(t, 2, cap2.Round)
+	assert.Equal(t, 6, len(s.Timeline()))
+}
+
+func TestApiEmptyWeaponName(t *testing.T) {
+	var a ApiResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"version": 3, "players": {"[U:1:57823119]": {"team": "Red", "class_stats": [
+		{"type": "soldier", "kills": 1, "weapon": {"": {"kills": 1, "dmg": 90}, "quake_rl": {"kills": 1, "dmg": 90, "avg_dmg": 90}}}
+	]}}}`), &a))
+	s := a.Summary()
+	p := s.Players[76561198018088847]
+	require.NotNil(t, p)
+	require.Equal(t, 1, len(p.Weapons))
+	assert.Equal(t, 90, p.Weapons["quake_rl"].Dmg)
+}
@@ -0,0 +1,68 @@
+package logstf
+
+import (
+	"strings"
+)
+
+// BuildingStats tracks the buildings a player has built and destroyed. Sappers placed by
+// spies are counted as OBJ_ATTACHMENT_SAPPER.
+type BuildingStats struct {
+	Built          map[string]int   // Buildings placed by object type eg. OBJ_SENTRYGUN
+	Destroyed      map[string]int   // Enemy buildings destroyed by object type
+	DestroyAssists int              // Enemy buildings destroyed with our assistance
+	Lost           map[string]int   // Our buildings destroyed by the enemy by object type
+	Detonated      map[string]int   // Our buildings we destroyed ourselves by object type
+	Carries        int              // Buildings picked up
+	Redeploys      int              // Carried buildings placed down again
+	Damage         map[string]int64 // Damage done by our buildings by weapon eg. obj_sentrygun3
+	Kills          map[string]int   // Kills by our buildings by weapon
+}
+
+func NewBuildingStats() *BuildingStats {
+	return &BuildingStats{
+		Built:     map[string]int{},
+		Destroyed: map[string]int{},
+		Lost:      map[string]int{},
+		Detonated: map[string]int{},
+		Damage:    map[string]int64{},
+		Kills:     map[string]int{},
+	}
+}
+
+// isBuildingWeapon checks if the weapon is a building, including wrangled sentries
+func isBuildingWeapon(weapon string) bool {
+	return strings.HasPrefix(weapon, "obj_") || weapon == "wrangler_kill"
+}
+
+func (s *LogSummary) builtObject(player *Player, object string) {
+	player.Buildings.Built[object]++
+}
+
+func (s *LogSummary) carryObject(player *Player) {
+	player.Buildings.Carries++
+}
+
+func (s *LogSummary) dropObject(player *Player) {
+	player.Buildings.Redeploys++
+}
+
+func (s *LogSummary) detonatedObject(player *Player, object string) {
+	player.Buildings.Detonated[object]++
+}
+
+// killedObject records the destroyed building for the attacker and the owner. Assisted lines
+// are logged for the assister in addition to the line for the attacker.
+func (s *LogSummary) killedObject(player *Player, owner *Player, object string, assist bool) {
+	if assist {
+		player.Buildings.DestroyAssists++
+		return
+	}
+	if owner == player {
+		player.Buildings.Detonated[object]++
+		return
+	}
+	player.Buildings.Destroyed[object]++
+	if owner != nil {
+		owner.Buildings.Lost[object]++
+	}
+}
@@ -0,0 +1,33 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBuildingStats(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "Kwq<9><[U:1:96748980]><Blue>" spawned as "Engineer"`,
+		`L 07/10/2019 - 23:00:10: "Kwq<9><[U:1:96748980]><Blue>" triggered "player_builtobject" (object "OBJ_SENTRYGUN") (position "1 2 3")`,
+		`L 07/10/2019 - 23:00:20: "Kwq<9><[U:1:96748980]><Blue>" triggered "player_builtobject" (object "OBJ_DISPENSER") (position "1 2 3")`,
+		`L 07/10/2019 - 23:00:30: "Kwq<9><[U:1:96748980]><Blue>" triggered "damage" against "rad<6><[U:1:57823119]><Red>" (damage "30") (weapon "obj_sentrygun3")`,
+		`L 07/10/2019 - 23:00:30: "Kwq<9><[U:1:96748980]><Blue>" killed "rad<6><[U:1:57823119]><Red>" with "obj_sentrygun3" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:00:40: "Kwq<9><[U:1:96748980]><Blue>" triggered "player_carryobject" (object "OBJ_SENTRYGUN") (position "1 2 3")`,
+		`L 07/10/2019 - 23:00:50: "Kwq<9><[U:1:96748980]><Blue>" triggered "player_dropobject" (object "OBJ_SENTRYGUN") (position "4 5 6")`,
+		`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" triggered "killedobject" (object "OBJ_SENTRYGUN") (weapon "tf_projectile_rocket") (objectowner "Kwq<9><[U:1:96748980]><Blue>") (attacker_position "1 2 3")`,
+		`L 07/10/2019 - 23:01:00: "wonder<7><[U:1:34284979]><Red>" triggered "killedobject" (object "OBJ_SENTRYGUN") (objectowner "Kwq<9><[U:1:96748980]><Blue>") (assist "1") (assister_position "1 2 3") (attacker_position "4 5 6")`,
+		`L 07/10/2019 - 23:01:10: "Kwq<9><[U:1:96748980]><Blue>" triggered "object_detonated" (object "OBJ_DISPENSER") (position "1 2 3")`,
+	})
+	engy := s.Players[76561198057014708].Buildings
+	assert.Equal(t, map[string]int{"OBJ_SENTRYGUN": 1, "OBJ_DISPENSER": 1}, engy.Built)
+	assert.Equal(t, map[string]int{"OBJ_SENTRYGUN": 1}, engy.Lost)
+	assert.Equal(t, map[string]int{"OBJ_DISPENSER": 1}, engy.Detonated)
+	assert.Equal(t, 1, engy.Carries)
+	assert.Equal(t, 1, engy.Redeploys)
+	assert.Equal(t, map[string]int64{"obj_sentrygun3": 30}, engy.Damage)
+	assert.Equal(t, map[string]int{"obj_sentrygun3": 1}, engy.Kills)
+	assert.Equal(t, map[string]int{"OBJ_SENTRYGUN": 1}, s.Players[76561198018088847].Buildings.Destroyed)
+	assert.Equal(t, 1, s.Players[76561197994550707].Buildings.DestroyAssists)
+	assert.Equal(t, 0, len(s.Players[76561197994550707].Buildings.Destroyed))
+}
@@ -0,0 +1,82 @@
+package logstf
+
+// ClassMatrix counts events between two classes, indexed by the players own class and then
+// the class of the other player involved, eg. matrix[scout][pyro] for a scout killed by a pyro
+// in ClassDeaths
+type ClassMatrix map[PlayerClass]map[PlayerClass]int
+
+func (m ClassMatrix) add(own PlayerClass, other PlayerClass, count int) {
+	row, found := m[own]
+	if !found {
+		row = make(map[PlayerClass]int)
+		m[own] = row
+	}
+	row[other] += count
+}
+
+// Get returns the count for the pair of classes
+func (m ClassMatrix) Get(own PlayerClass, other PlayerClass) int {
+	return m[own][other]
+}
+
+// ByOther sums the counts over the players own classes, this is the same breakdown as the
+// logs.tf classkills, classdeaths and classkillassists stats
+func (m ClassMatrix) ByOther() map[PlayerClass]int {
+	totals := make(map[PlayerClass]int)
+	for _, row := range m {
+		for other, count := range row {
+			totals[other] += count
+		}
+	}
+	return totals
+}
+
+func (m ClassMatrix) merge(o ClassMatrix) {
+	for own, row := range o {
+		for other, count := range row {
+			m.add(own, other, count)
+		}
+	}
+}
+
+// classKill records a kill against the classes both players were on at the time. Players
+// that have not spawned yet have no class and are not counted.
+func (s *LogSummary) classKill(attacker *Player, victim *Player) {
+	if attacker.CurrentClass == spectator || victim.CurrentClass == spectator {
+		return
+	}
+	attacker.ClassKills.add(attacker.CurrentClass, victim.CurrentClass, 1)
+	victim.ClassDeaths.add(victim.CurrentClass, attacker.CurrentClass, 1)
+}
+
+func (s *LogSummary) classAssist(assister *Player, victim *Player) {
+	if victim == nil || assister.CurrentClass == spectator || victim.CurrentClass == spectator {
+		return
+	}
+	assister.ClassKillAssists.add(assister.CurrentClass, victim.CurrentClass, 1)
+}
+
+// TeamClassKills returns the class kill matrix for all players on the team
+func (s *LogSummary) TeamClassKills(team Team) ClassMatrix {
+	return s.teamMatrix(team, func(p *Player) ClassMatrix { return p.ClassKills })
+}
+
+// TeamClassDeaths returns the class death matrix for all players on the team
+func (s *LogSummary) TeamClassDeaths(team Team) ClassMatrix {
+	return s.teamMatrix(team, func(p *Player) ClassMatrix { return p.ClassDeaths })
+}
+
+// TeamClassKillAssists returns the class assist matrix for all players on the team
+func (s *LogSummary) TeamClassKillAssists(team Team) ClassMatrix {
+	return s.teamMatrix(team, func(p *Player) ClassMatrix { return p.ClassKillAssists })
+}
+
+func (s *LogSummary) teamMatrix(team Team, matrix func(p *Player) ClassMatrix) ClassMatrix {
+	m := make(ClassMatrix)
+	for _, p := range s.Players {
+		if p.Team == team {
+			m.merge(matrix(p))
+		}
+	}
+	return m
+}
@@ -0,0 +1,43 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClassMatrix(t *testing.T) {
+	s := applyLines(t, classTestLines)
+	rad := s.Players[76561198018088847]
+	zed := s.Players[76561198026922576]
+	med := s.Players[76561198056213049]
+	assert.Equal(t, ClassMatrix{soldier: {scout: 1}}, rad.ClassKills)
+	assert.Equal(t, ClassMatrix{pyro: {scout: 1}}, rad.ClassDeaths)
+	assert.Equal(t, ClassMatrix{scout: {pyro: 1}}, zed.ClassKills)
+	assert.Equal(t, ClassMatrix{scout: {soldier: 1}}, zed.ClassDeaths)
+	assert.Equal(t, 1, med.ClassKillAssists.Get(medic, pyro))
+	assert.Equal(t, map[PlayerClass]int{scout: 1}, rad.ClassDeaths.ByOther())
+
+	blu := s.TeamClassKills(BLU)
+	assert.Equal(t, ClassMatrix{scout: {pyro: 1}}, blu)
+	assert.Equal(t, ClassMatrix{medic: {pyro: 1}}, s.TeamClassKillAssists(BLU))
+	assert.Equal(t, ClassMatrix{pyro: {scout: 1}}, s.TeamClassDeaths(RED))
+}
+
+func TestClassMatrixChangedRole(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+		`L 07/10/2019 - 23:00:00: "wonder<7><[U:1:34284979]><Red>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" changed role to "Pyro"`,
+		`L 07/10/2019 - 23:01:00: "z/<14><[U:1:66656848]><Blue>" changed role to "Sniper"`,
+		`L 07/10/2019 - 23:01:00: "wonder<7><[U:1:34284979]><Red>" changed role to "Engineer"`,
+		`L 07/10/2019 - 23:02:00: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:02:00: "wonder<7><[U:1:34284979]><Red>" triggered "kill assist" against "z/<14><[U:1:66656848]><Blue>" (assister_position "1 2 3") (attacker_position "4 5 6") (victim_position "7 8 9")`,
+	})
+	rad := s.Players[76561198018088847]
+	zed := s.Players[76561198026922576]
+	assert.Equal(t, ClassMatrix{soldier: {scout: 1}}, rad.ClassKills)
+	assert.Equal(t, ClassMatrix{scout: {soldier: 1}}, zed.ClassDeaths)
+	assert.Equal(t, ClassMatrix{medic: {scout: 1}}, s.Players[76561197994550707].ClassKillAssists)
+}
@@ -0,0 +1,67 @@
+package logstf
+
+import (
+	"time"
+)
+
+// Pause is a single Game_Paused to Game_Unpaused interval
+type Pause struct {
+	Start time.Time
+	End   time.Time // Zero while still paused
+}
+
+// Duration returns the length of the pause, up to until for a pause that has not ended
+func (p Pause) Duration(until time.Time) time.Duration {
+	end := p.End
+	if end.IsZero() {
+		end = until
+	}
+	if end.Before(p.Start) {
+		return 0
+	}
+	return end.Sub(p.Start)
+}
+
+// PausedTime returns the total time the match was paused
+func (s *LogSummary) PausedTime() time.Duration {
+	var total time.Duration
+	for _, p := range s.Pauses {
+		total += p.Duration(s.now)
+	}
+	return total
+}
+
+// pausedBetween returns how much of the interval was spent paused
+func (s *LogSummary) pausedBetween(from time.Time, to time.Time) time.Duration {
+	var total time.Duration
+	for _, p := range s.Pauses {
+		start, end := p.Start, p.End
+		if end.IsZero() {
+			end = s.now
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// gameDuration returns the time between from and to with any pauses removed
+func (s *LogSummary) gameDuration(from time.Time, to time.Time) time.Duration {
+	if from.IsZero() || !to.After(from) {
+		return 0
+	}
+	return to.Sub(from) - s.pausedBetween(from, to)
+}
+
+// GameTime converts the wall clock time of an event into the time since the first round
+// started with the pauses removed
+func (s *LogSummary) GameTime(ts time.Time) time.Duration {
+	return s.gameDuration(s.matchStart, ts)
+}
@@ -0,0 +1,58 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestMatchClock(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:30: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "900") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:01:00: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:01:10: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:03:00: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:03:05: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:05:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:05:00: World triggered "Round_Length" (seconds "300.00")`,
+	})
+	start := time.Date(2019, 7, 10, 23, 0, 0, 0, time.UTC)
+	require.Equal(t, []Pause{{Start: start.Add(time.Minute), End: start.Add(3 * time.Minute)}}, s.Pauses)
+	assert.Equal(t, 2*time.Minute, s.PausedTime())
+	assert.Equal(t, 30*time.Second, s.GameTime(start.Add(30*time.Second)))
+	assert.Equal(t, time.Minute, s.GameTime(start.Add(2*time.Minute)))
+	assert.Equal(t, 2*time.Minute, s.GameTime(start.Add(4*time.Minute)))
+	require.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, 5*time.Minute, s.Rounds[0].Length)
+	assert.Equal(t, 3*time.Minute, s.Rounds[0].LengthRt)
+	assert.Equal(t, 3*time.Minute, s.TotalLength())
+	rad := s.Players[76561198018088847]
+	assert.Equal(t, 3*time.Minute, rad.TimePlayed())
+	assert.Equal(t, 300.0, rad.DamagePerMin())
+
+	// Without round times the server length is used
+	s.Rounds[0].LengthRt = 0
+	assert.Equal(t, 5*time.Minute, s.TotalLength())
+}
+
+func TestEventGameTime(t *testing.T) {
+	s := NewSummary()
+	var times []time.Duration
+	for _, line := range []string{
+		`L 07/10/2019 - 22:59:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:30: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:01:00: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:03:00: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:03:30: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`,
+	} {
+		ev, err := ParseEvent(line)
+		require.NoError(t, err)
+		s.ApplyEvent(ev)
+		times = append(times, ev.eventBase().GameTime)
+	}
+	assert.Equal(t, []time.Duration{0, 0, 30 * time.Second, time.Minute, time.Minute, 90 * time.Second}, times)
+}
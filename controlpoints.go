@@ -0,0 +1,210 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"time"
+)
+
+// midPoint5CP is the cp index of the middle point on standard 5CP maps, the points are numbered
+// 0 to 4 from one last point to the other
+const midPoint5CP = 2
+
+// PointKey identifies a control point. The cp index alone is not unique on maps with stages
+// where each stage numbers its points from zero again.
+type PointKey struct {
+	CP   int
+	Name string
+}
+
+// PushDirection tells if a capture took ground from the enemy or won back a teams own point
+type PushDirection int
+
+const (
+	PushForward PushDirection = iota
+	PushBackward
+)
+
+func (d PushDirection) String() string {
+	if d == PushBackward {
+		return "backward"
+	}
+	return "forward"
+}
+
+// ControlPoint is the state of a single point within a round
+type ControlPoint struct {
+	Point    PointKey
+	Owner    Team // SPEC while neutral or not captured yet in the round
+	Captures int
+	Blocks   int
+	home     Team // Owner at the start of the round, SPEC when neutral or unknown
+}
+
+// PointCapture is a single capture of a point
+type PointCapture struct {
+	Point     PointKey
+	Team      Team
+	Previous  Team          // Owner before the capture, SPEC when neutral or unknown
+	Time      time.Duration // Game time since the start of the round
+	CreatedOn time.Time
+	Cappers   []steamid.SID64
+	Direction PushDirection
+}
+
+// CaptureBlock is a player stopping a capture of their teams point
+type CaptureBlock struct {
+	Point     PointKey
+	Team      Team
+	SteamId   steamid.SID64
+	Time      time.Duration // Game time since the start of the round
+	CreatedOn time.Time
+}
+
+func otherTeam(team Team) Team {
+	switch team {
+	case RED:
+		return BLU
+	case BLU:
+		return RED
+	default:
+		return SPEC
+	}
+}
+
+// point returns the state of the point for the round, creating it on first use
+func (r *RoundSummary) point(key PointKey) *ControlPoint {
+	if r.Points == nil {
+		r.Points = make(map[PointKey]*ControlPoint)
+	}
+	cp, found := r.Points[key]
+	if !found {
+		cp = &ControlPoint{Point: key, home: SPEC}
+		r.Points[key] = cp
+	}
+	return cp
+}
+
+// isNeutralPoint returns true for points nobody owns when the round starts, the middle point on
+// 5CP and the single point on KOTH
+func (s *LogSummary) isNeutralPoint(key PointKey) bool {
+	switch {
+	case s.GameMode == ModeKOTH || s.GameMode == ModeUltiduo:
+		return true
+	case s.GameMode == Mode5CP:
+		return key.CP == midPoint5CP
+	default:
+		return false
+	}
+}
+
+// isMidFight returns true when the capture decides the midfight, the first capture of the
+// middle point on 5CP and the first capture of the round on other modes
+func (s *LogSummary) isMidFight(key PointKey) bool {
+	if s.currentRoundSummary.MidFight != SPEC {
+		return false
+	}
+	return s.GameMode != Mode5CP || key.CP == midPoint5CP
+}
+
+// pointCaptured updates the point owner for the round. Nobody can take back a point before it
+// was lost, so the first capture of a point that is not neutral tells who owned it at the
+// start of the round and any later capture by that team is a backward push.
+func (s *LogSummary) pointCaptured(team Team, cp int, cpName string, cappers []*Player) {
+	r := s.currentRoundSummary
+	if !s.roundStarted || r == nil {
+		return
+	}
+	key := PointKey{CP: cp, Name: cpName}
+	point := r.point(key)
+	if point.Captures == 0 && !s.isNeutralPoint(key) {
+		point.home = otherTeam(team)
+	}
+	capture := PointCapture{
+		Point:     key,
+		Team:      team,
+		Previous:  point.Owner,
+		Time:      s.gameDuration(s.roundStartTime, s.now),
+		CreatedOn: s.now,
+		Direction: PushForward,
+	}
+	if point.home == team {
+		capture.Direction = PushBackward
+	}
+	for _, p := range cappers {
+		capture.Cappers = append(capture.Cappers, p.SteamId)
+	}
+	point.Owner = team
+	point.Captures++
+	r.Captures = append(r.Captures, capture)
+	if s.isMidFight(key) {
+		r.MidFight = team
+		r.MidFightTime = capture.Time
+		s.getTeamSummary(team).MidFights++
+	}
+	s.lastPointCaptured(team, key, point)
+}
+
+// lastPointCaptured tracks teams defending their last point on 5CP. A team is on last once it
+// loses its second point, it holds when it captures any point back or the round ends without
+// the enemy winning.
+func (s *LogSummary) lastPointCaptured(team Team, key PointKey, point *ControlPoint) {
+	if s.GameMode != Mode5CP {
+		return
+	}
+	r := s.currentRoundSummary
+	if r.lastHold == team {
+		s.getTeamSummary(team).LastHolds++
+		r.lastHold = SPEC
+	}
+	if point.home != SPEC && point.home != team && (key.CP == midPoint5CP-1 || key.CP == midPoint5CP+1) {
+		r.lastHold = point.home
+	}
+}
+
+// endLastHold settles a last point defence still going when the round ends
+func (s *LogSummary) endLastHold(winner Team) {
+	r := s.currentRoundSummary
+	if r == nil || r.lastHold == SPEC {
+		return
+	}
+	if winner == otherTeam(r.lastHold) {
+		s.getTeamSummary(r.lastHold).LastLosses++
+	} else {
+		s.getTeamSummary(r.lastHold).LastHolds++
+	}
+	r.lastHold = SPEC
+}
+
+func (s *LogSummary) pointBlocked(player *Player, team Team, cp int, cpName string) {
+	r := s.currentRoundSummary
+	if !s.roundStarted || r == nil {
+		return
+	}
+	key := PointKey{CP: cp, Name: cpName}
+	r.point(key).Blocks++
+	r.Blocks = append(r.Blocks, CaptureBlock{
+		Point:     key,
+		Team:      team,
+		SteamId:   player.SteamId,
+		Time:      s.gameDuration(s.roundStartTime, s.now),
+		CreatedOn: s.now,
+	})
+}
+
+// AvgMidFightTime returns the average game time it took to win the midfight, only counting the
+// midfights won by team unless team is SPEC
+func (s *LogSummary) AvgMidFightTime(team Team) time.Duration {
+	var total time.Duration
+	var count int
+	for _, r := range s.Rounds {
+		if r.MidFight == SPEC || (team != SPEC && r.MidFight != team) || r.MidFightTime == 0 {
+			continue
+		}
+		total += r.MidFightTime
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
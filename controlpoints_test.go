@@ -0,0 +1,82 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestControlPoints(t *testing.T) {
+	// Blue does not capture until red is on their second
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: Loading map "cp_badlands"`,
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:40: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "2") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7") (player2 "wonder<7><[U:1:34284979]><Red>") (position2 "-105 118 5")`,
+		`L 07/10/2019 - 23:01:30: Team "Red" triggered "pointcaptured" (cp "3") (cpname "#Badlands_cap_cp4") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:01:50: "Graba<3><[U:1:95947321]><Blue>" triggered "captureblocked" (cp "4") (cpname "#Badlands_cap_cp5") (position "-266 343 0")`,
+		`L 07/10/2019 - 23:02:30: Team "Blue" triggered "pointcaptured" (cp "3") (cpname "#Badlands_cap_cp4") (numcappers "1") (player1 "Graba<3><[U:1:95947321]><Blue>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:03:00: Team "Red" triggered "pointcaptured" (cp "3") (cpname "#Badlands_cap_cp4") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:03:30: Team "Red" triggered "pointcaptured" (cp "4") (cpname "#Badlands_cap_cp5") (numcappers "1") (player1 "wonder<7><[U:1:34284979]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:03:30: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:03:30: World triggered "Round_Length" (seconds "210.00")`,
+		`L 07/10/2019 - 23:04:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:04:20: Team "Blue" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "1") (player1 "Graba<3><[U:1:95947321]><Blue>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:05:00: World triggered "Round_Win" (winner "Blue")`,
+		`L 07/10/2019 - 23:05:00: World triggered "Round_Length" (seconds "60.00")`,
+	})
+	require.Equal(t, Mode5CP, s.GameMode)
+	require.Equal(t, 2, len(s.Rounds))
+	rad, wonder := steamid.SID64(76561198018088847), steamid.SID64(76561197994550707)
+
+	r := s.Rounds[0]
+	assert.Equal(t, RED, r.Winner)
+	assert.Equal(t, RED, r.MidFight)
+	assert.Equal(t, 40*time.Second, r.MidFightTime)
+	require.Equal(t, 5, len(r.Captures))
+	second := PointKey{CP: 3, Name: "#Badlands_cap_cp4"}
+	assert.Equal(t, PointCapture{Point: PointKey{CP: 2, Name: "#Badlands_cap_cp3"}, Team: RED, Previous: SPEC,
+		Time: 40 * time.Second, CreatedOn: time.Date(2019, 7, 10, 23, 0, 40, 0, time.UTC),
+		Cappers: []steamid.SID64{rad, wonder}, Direction: PushForward}, r.Captures[0])
+	var directions []PushDirection
+	for _, c := range r.Captures {
+		directions = append(directions, c.Direction)
+	}
+	assert.Equal(t, []PushDirection{PushForward, PushForward, PushBackward, PushForward, PushForward}, directions)
+	assert.Equal(t, RED, r.Captures[2].Previous)
+	assert.Equal(t, RED, r.Points[second].Owner)
+	assert.Equal(t, 3, r.Points[second].Captures)
+
+	require.Equal(t, 1, len(r.Blocks))
+	assert.Equal(t, BLU, r.Blocks[0].Team)
+	assert.Equal(t, 110*time.Second, r.Blocks[0].Time)
+	assert.Equal(t, 1, r.Points[PointKey{CP: 4, Name: "#Badlands_cap_cp5"}].Blocks)
+
+	assert.Equal(t, BLU, s.Rounds[1].MidFight)
+	assert.Equal(t, 20*time.Second, s.Rounds[1].MidFightTime)
+	assert.Equal(t, 1, s.Teams[RED].MidFights)
+	assert.Equal(t, 1, s.Teams[BLU].MidFights)
+	assert.Equal(t, 40*time.Second, s.AvgMidFightTime(RED))
+	assert.Equal(t, 30*time.Second, s.AvgMidFightTime(SPEC))
+
+	assert.Equal(t, 1, s.Teams[BLU].LastHolds)
+	assert.Equal(t, 1, s.Teams[BLU].LastLosses)
+	assert.Equal(t, 0, s.Teams[RED].LastHolds)
+}
+
+func TestMidFightKOTH(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: Loading map "koth_product_rcx"`,
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:01:00: Team "Blue" triggered "pointcaptured" (cp "0") (cpname "#koth_viaduct_cap") (numcappers "1") (player1 "Graba<3><[U:1:95947321]><Blue>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:02:00: Team "Red" triggered "pointcaptured" (cp "0") (cpname "#koth_viaduct_cap") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:05:00: World triggered "Round_Win" (winner "Red")`,
+	})
+	require.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, BLU, s.Rounds[0].MidFight)
+	assert.Equal(t, time.Minute, s.Rounds[0].MidFightTime)
+	require.Equal(t, 2, len(s.Rounds[0].Captures))
+	assert.Equal(t, PushForward, s.Rounds[0].Captures[1].Direction)
+	assert.Equal(t, BLU, s.Rounds[0].Captures[1].Previous)
+}
@@ -46,7 +46,7 @@ $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
 
 func insertPlayerClasses(tx *sqlx.Tx, s *LogSummary) error {
 	for sid, player := range s.Players {
-		for _, cls := range player.Classes {
+		for cls := range player.Classes {
 			stmt, err := tx.Preparex(`
 			INSERT INTO logstf_player_classes (
 				log_id, steam_id, class_id
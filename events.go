@@ -0,0 +1,752 @@
+package logstf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnhandledLine is returned by ParseEvent when a line does not match any known message
+	ErrUnhandledLine = errors.New("unhandled log line")
+	// ErrSkippedLine is returned by ParseEvent for known lines that carry no useful data
+	ErrSkippedLine = errors.New("skipped log line")
+	// ErrCapperCount is reported when the cappers listed on a pointcaptured line do not match
+	// its numcappers
+	ErrCapperCount = errors.New("capper count does not match numcappers")
+)
+
+// Event is implemented by all of the typed messages returned from ParseEvent. Use a type switch
+// on the concrete *XxxEvent types to access the message specific fields.
+type Event interface {
+	// MsgType returns the internal message type of the event
+	MsgType() MsgType
+	// Timestamp returns the time the server logged the event
+	Timestamp() time.Time
+	eventBase() *EventBase
+}
+
+// EventBase is embedded into all events. It holds the time the event was logged and every
+// `(key "value")` property of the line, including those without a dedicated event field.
+type EventBase struct {
+	CreatedOn  time.Time
+	Properties Properties
+	Phase      MatchPhase    // Set when the event is applied to a summary
+	GameTime   time.Duration // Time since the first round started with the pauses removed, set with Phase
+}
+
+func (e EventBase) Timestamp() time.Time {
+	return e.CreatedOn
+}
+
+func (e *EventBase) eventBase() *EventBase {
+	return e
+}
+
+// newPlayerRef builds the player from the separate regex groups, an empty PlayerRef is returned
+// when the groups were not matched
+func newPlayerRef(name, pid, sid, team string) PlayerRef {
+	ref, err := ParsePlayerRef(name + "<" + pid + "><" + sid + "><" + team + ">")
+	if err != nil {
+		return PlayerRef{}
+	}
+	return ref
+}
+
+type ConnectedEvent struct {
+	EventBase
+	Player  PlayerRef
+	Address string
+}
+
+type DisconnectedEvent struct {
+	EventBase
+	Player PlayerRef
+	Reason string
+}
+
+type ValidatedEvent struct {
+	EventBase
+	Player PlayerRef
+}
+
+type EnteredEvent struct {
+	EventBase
+	Player PlayerRef
+}
+
+type JoinedTeamEvent struct {
+	EventBase
+	Player  PlayerRef
+	NewTeam Team
+}
+
+type ChangeClassEvent struct {
+	EventBase
+	Player PlayerRef
+	Class  PlayerClass
+}
+
+type SpawnedAsEvent struct {
+	EventBase
+	Player PlayerRef
+	Class  PlayerClass
+}
+
+type SuicideEvent struct {
+	EventBase
+	Player      PlayerRef
+	Weapon      string
+	AttackerPos Position
+}
+
+type ShotFiredEvent struct {
+	EventBase
+	Player PlayerRef
+	Weapon string
+}
+
+type ShotHitEvent struct {
+	EventBase
+	Player PlayerRef
+	Weapon string
+}
+
+// DamageEvent is a single instance of damage. Victim is empty on older logs which did not
+// include the target of the damage. Crit is either "crit" or "mini" when set.
+type DamageEvent struct {
+	EventBase
+	Player     PlayerRef
+	Victim     PlayerRef
+	Damage     int64
+	RealDamage int64
+	Weapon     string
+	Healing    int64
+	Crit       string
+	Headshot   bool
+	Airshot    bool
+}
+
+// KillEvent is a player kill. CustomKill holds values such as headshot or backstab when set.
+type KillEvent struct {
+	EventBase
+	Player      PlayerRef
+	Victim      PlayerRef
+	Weapon      string
+	CustomKill  string
+	AttackerPos Position
+	VictimPos   Position
+}
+
+type KillAssistEvent struct {
+	EventBase
+	Player      PlayerRef
+	Victim      PlayerRef
+	AssisterPos Position
+	AttackerPos Position
+	VictimPos   Position
+}
+
+type DominationEvent struct {
+	EventBase
+	Player PlayerRef
+	Victim PlayerRef
+}
+
+type RevengeEvent struct {
+	EventBase
+	Player PlayerRef
+	Victim PlayerRef
+	Assist bool
+}
+
+type PickupEvent struct {
+	EventBase
+	Player  PlayerRef
+	Item    string
+	Healing int64 // Health restored by the item, only logged by newer servers
+}
+
+type SayEvent struct {
+	EventBase
+	Player   PlayerRef
+	Message  string
+	TeamChat bool
+}
+
+type EmptyUberEvent struct {
+	EventBase
+	Player PlayerRef
+}
+
+// MedicDeathEvent is sent when Player kills the medic Victim
+type MedicDeathEvent struct {
+	EventBase
+	Player  PlayerRef
+	Victim  PlayerRef
+	Healing int64
+	HadUber bool
+}
+
+type MedicDeathExEvent struct {
+	EventBase
+	Player  PlayerRef
+	UberPct int64
+}
+
+type LostUberAdvantageEvent struct {
+	EventBase
+	Player PlayerRef
+	Time   int64
+}
+
+type ChargeReadyEvent struct {
+	EventBase
+	Player PlayerRef
+}
+
+type ChargeDeployedEvent struct {
+	EventBase
+	Player  PlayerRef
+	Medigun Medigun
+}
+
+type ChargeEndedEvent struct {
+	EventBase
+	Player   PlayerRef
+	Duration float64
+}
+
+type HealedEvent struct {
+	EventBase
+	Player  PlayerRef
+	Target  PlayerRef
+	Healing int64
+}
+
+type ExtinguishedEvent struct {
+	EventBase
+	Player      PlayerRef
+	Target      PlayerRef
+	Weapon      string
+	AttackerPos Position
+	VictimPos   Position
+}
+
+// DeflectedEvent is sent when Player reflects a projectile fired by Owner
+type DeflectedEvent struct {
+	EventBase
+	Player   PlayerRef
+	Owner    PlayerRef
+	Weapon   string // The projectile reflected eg. tf_projectile_rocket
+	Position Position
+}
+
+// PlayerDeflectEvent is sent when Player airblasts the Victim
+type PlayerDeflectEvent struct {
+	EventBase
+	Player      PlayerRef
+	Victim      PlayerRef
+	Weapon      string
+	AttackerPos Position
+	VictimPos   Position
+}
+
+type BuiltObjectEvent struct {
+	EventBase
+	Player   PlayerRef
+	Object   string
+	Position Position
+}
+
+type CarryObjectEvent struct {
+	EventBase
+	Player   PlayerRef
+	Object   string
+	Position Position
+}
+
+type DropObjectEvent struct {
+	EventBase
+	Player   PlayerRef
+	Object   string
+	Position Position
+}
+
+type KilledObjectEvent struct {
+	EventBase
+	Player      PlayerRef
+	Owner       PlayerRef
+	Object      string
+	Weapon      string
+	Assist      bool
+	AssisterPos Position
+	AttackerPos Position
+}
+
+type DetonatedObjectEvent struct {
+	EventBase
+	Player   PlayerRef
+	Object   string
+	Position Position
+}
+
+type FirstHealAfterSpawnEvent struct {
+	EventBase
+	Player   PlayerRef
+	HealTime time.Duration
+}
+
+type CaptureBlockedEvent struct {
+	EventBase
+	Player   PlayerRef
+	CP       int
+	CPName   string
+	Position Position
+}
+
+// PointCapturedEvent is sent by the server for a team capturing a point. Cappers and Positions
+// are ordered the same as the player1..N properties of the line.
+type PointCapturedEvent struct {
+	EventBase
+	Team       Team
+	CP         int
+	CPName     string
+	NumCappers int
+	Cappers    []PlayerRef
+	Positions  []Position
+}
+
+// FlagEvent is a change in state of the intel in CTF modes. Action is one of FlagPickedUp,
+// FlagDropped, FlagCaptured or FlagDefended.
+type FlagEvent struct {
+	EventBase
+	Player   PlayerRef
+	Action   string
+	Position Position
+}
+
+type RoundOvertimeEvent struct {
+	EventBase
+}
+
+type RoundStartEvent struct {
+	EventBase
+}
+
+type RoundWinEvent struct {
+	EventBase
+	Winner Team
+}
+
+type RoundLengthEvent struct {
+	EventBase
+	Length time.Duration
+}
+
+// TeamScoreEvent is used for both the current and final score lines
+type TeamScoreEvent struct {
+	EventBase
+	Team    Team
+	Score   int
+	Players int
+	Final   bool
+}
+
+type GameOverEvent struct {
+	EventBase
+	Reason string
+}
+
+type PausedEvent struct {
+	EventBase
+}
+
+type UnpausedEvent struct {
+	EventBase
+}
+
+// LogStartedEvent is the first line of every log file the server writes
+type LogStartedEvent struct {
+	EventBase
+	File    string
+	Game    string
+	Version string
+}
+
+type LoadingMapEvent struct {
+	EventBase
+	Map string
+}
+
+type StartedMapEvent struct {
+	EventBase
+	Map string
+}
+
+// ServerCvarEvent is a cvar change, or one of the cvars listed when a map starts when Listing
+// is set
+type ServerCvarEvent struct {
+	EventBase
+	Name    string
+	Value   string
+	Listing bool
+}
+
+type TournamentStartedEvent struct {
+	EventBase
+}
+
+// TeamNameEvent is the team name logged after the tournament mode is started
+type TeamNameEvent struct {
+	EventBase
+	Team Team
+	Name string
+}
+
+// RconEvent is a command sent to the server over rcon
+type RconEvent struct {
+	EventBase
+	Address string
+	Command string
+}
+
+func (*ConnectedEvent) MsgType() MsgType           { return connected }
+func (*DisconnectedEvent) MsgType() MsgType        { return disconnected }
+func (*ValidatedEvent) MsgType() MsgType           { return validated }
+func (*EnteredEvent) MsgType() MsgType             { return entered }
+func (*JoinedTeamEvent) MsgType() MsgType          { return joinedTeam }
+func (*ChangeClassEvent) MsgType() MsgType         { return changeClass }
+func (*SpawnedAsEvent) MsgType() MsgType           { return spawnedAs }
+func (*SuicideEvent) MsgType() MsgType             { return suicide }
+func (*ShotFiredEvent) MsgType() MsgType           { return shotFired }
+func (*ShotHitEvent) MsgType() MsgType             { return shotHit }
+func (*DamageEvent) MsgType() MsgType              { return damage }
+func (*KillAssistEvent) MsgType() MsgType          { return killAssist }
+func (*DominationEvent) MsgType() MsgType          { return domination }
+func (*RevengeEvent) MsgType() MsgType             { return revenge }
+func (*PickupEvent) MsgType() MsgType              { return pickup }
+func (*EmptyUberEvent) MsgType() MsgType           { return emptyUber }
+func (*MedicDeathEvent) MsgType() MsgType          { return medicDeath }
+func (*MedicDeathExEvent) MsgType() MsgType        { return medicDeathEx }
+func (*LostUberAdvantageEvent) MsgType() MsgType   { return lostUberAdv }
+func (*ChargeReadyEvent) MsgType() MsgType         { return chargeReady }
+func (*ChargeDeployedEvent) MsgType() MsgType      { return chargeDeployed }
+func (*ChargeEndedEvent) MsgType() MsgType         { return chargeEnded }
+func (*HealedEvent) MsgType() MsgType              { return healed }
+func (*ExtinguishedEvent) MsgType() MsgType        { return extinguished }
+func (*DeflectedEvent) MsgType() MsgType           { return objectDeflected }
+func (*PlayerDeflectEvent) MsgType() MsgType       { return playerDeflect }
+func (*BuiltObjectEvent) MsgType() MsgType         { return builtObject }
+func (*CarryObjectEvent) MsgType() MsgType         { return carryObject }
+func (*DropObjectEvent) MsgType() MsgType          { return dropObject }
+func (*KilledObjectEvent) MsgType() MsgType        { return killedObject }
+func (*DetonatedObjectEvent) MsgType() MsgType     { return detonatedObject }
+func (*FirstHealAfterSpawnEvent) MsgType() MsgType { return firstHealAfterSpawn }
+func (*CaptureBlockedEvent) MsgType() MsgType      { return captureBlocked }
+func (*PointCapturedEvent) MsgType() MsgType       { return pointCaptured }
+func (*FlagEvent) MsgType() MsgType                { return flagEvent }
+func (*RoundOvertimeEvent) MsgType() MsgType       { return wRoundOvertime }
+func (*RoundStartEvent) MsgType() MsgType          { return wRoundStart }
+func (*RoundWinEvent) MsgType() MsgType            { return wRoundWin }
+func (*RoundLengthEvent) MsgType() MsgType         { return wRoundLen }
+func (*GameOverEvent) MsgType() MsgType            { return wGameOver }
+func (*PausedEvent) MsgType() MsgType              { return wPaused }
+func (*UnpausedEvent) MsgType() MsgType            { return wUnpaused }
+func (*LogStartedEvent) MsgType() MsgType          { return logStarted }
+func (*LoadingMapEvent) MsgType() MsgType          { return loadingMap }
+func (*StartedMapEvent) MsgType() MsgType          { return startedMap }
+func (*RconEvent) MsgType() MsgType                { return rcon }
+func (*ServerCvarEvent) MsgType() MsgType          { return serverCvar }
+func (*TournamentStartedEvent) MsgType() MsgType   { return tournamentStarted }
+func (*TeamNameEvent) MsgType() MsgType            { return tournamentTeamName }
+
+func (e *KillEvent) MsgType() MsgType {
+	if e.CustomKill != "" {
+		return killedCustom
+	}
+	return killed
+}
+
+func (e *SayEvent) MsgType() MsgType {
+	if e.TeamChat {
+		return sayTeam
+	}
+	return say
+}
+
+func (e *TeamScoreEvent) MsgType() MsgType {
+	if e.Final {
+		return wTeamFinalScore
+	}
+	return wTeamScore
+}
+
+// ParseEvent parses a single log line into one of the typed events. ErrSkippedLine is returned
+// for lines which are known but ignored and ErrUnhandledLine when nothing matches. A required
+// value failing to convert returns a *FieldError. When only optional values such as positions
+// fail, the event is returned along with a FieldErrors error.
+func ParseEvent(line string) (Event, error) {
+	return ParseEventWithOptions(line, ParseOptions{})
+}
+
+// ParseEventWithOptions is ParseEvent with control over the date layout and timezone. A line
+// with an invalid timestamp returns a *FieldError for the "date" field.
+func ParseEventWithOptions(line string, opts ParseOptions) (Event, error) {
+	return lexLine(strings.TrimRight(line, "\r\n"), opts)
+}
+
+// parseEventRx is the original regex based implementation of ParseEvent. It is kept as the
+// reference the lexer is tested and benchmarked against.
+func parseEventRx(line string) (Event, error) {
+	line = strings.TrimRight(line, "\r\n")
+	d, msgType := parseLine(line)
+	switch msgType {
+	case unhandledMsg:
+		return nil, ErrUnhandledLine
+	case skipped:
+		return nil, ErrSkippedLine
+	}
+	props := findProperties(line)
+	ev, err := newEvent(d, msgType, props)
+	if ev != nil {
+		ev.eventBase().Properties = props
+	}
+	return ev, err
+}
+
+// newEvent converts the named groups of a matched line into its typed event
+func newEvent(d map[string]string, msgType MsgType, props Properties) (Event, error) {
+	createdOn, err := parseLogTime(d["date"], d["time"], ParseOptions{})
+	if err != nil {
+		return nil, &FieldError{Field: "date", Value: d["date"] + " - " + d["time"], Err: err}
+	}
+	et := EventBase{CreatedOn: createdOn}
+	p1 := newPlayerRef(d["name"], d["pid"], d["sid"], d["team"])
+	p2 := newPlayerRef(d["name2"], d["pid2"], d["sid2"], d["team2"])
+	switch msgType {
+	case connected:
+		return &ConnectedEvent{EventBase: et, Player: p1, Address: d["address"]}, nil
+	case disconnected:
+		return &DisconnectedEvent{EventBase: et, Player: p1, Reason: d["reason"]}, nil
+	case validated:
+		return &ValidatedEvent{EventBase: et, Player: p1}, nil
+	case entered:
+		return &EnteredEvent{EventBase: et, Player: p1}, nil
+	case joinedTeam:
+		return &JoinedTeamEvent{EventBase: et, Player: p1, NewTeam: parseTeam(d["newteam"])}, nil
+	case changeClass:
+		return &ChangeClassEvent{EventBase: et, Player: p1, Class: parsePlayerClass(d["class"])}, nil
+	case spawnedAs:
+		return &SpawnedAsEvent{EventBase: et, Player: p1, Class: parsePlayerClass(d["class"])}, nil
+	case suicide:
+		return &SuicideEvent{EventBase: et, Player: p1, Weapon: "world", AttackerPos: parsePos(d["pos"])}, nil
+	case shotFired:
+		return &ShotFiredEvent{EventBase: et, Player: p1, Weapon: d["weapon"]}, nil
+	case shotHit:
+		return &ShotHitEvent{EventBase: et, Player: p1, Weapon: d["weapon"]}, nil
+	case damage:
+		return newDamageEvent(et, p1, p2, d, props)
+	case killed, killedCustom:
+		return &KillEvent{EventBase: et, Player: p1, Victim: p2, Weapon: d["weapon"], CustomKill: d["customkill"],
+			AttackerPos: parsePos(d["apos"]), VictimPos: parsePos(d["vpos"])}, nil
+	case killAssist:
+		return &KillAssistEvent{EventBase: et, Player: p1, Victim: p2, AssisterPos: parsePos(d["aspos"]),
+			AttackerPos: parsePos(d["apos"]), VictimPos: parsePos(d["vpos"])}, nil
+	case domination:
+		return &DominationEvent{EventBase: et, Player: p1, Victim: p2}, nil
+	case revenge:
+		return &RevengeEvent{EventBase: et, Player: p1, Victim: p2, Assist: d["assist"] == "1"}, nil
+	case pickup:
+		ev := &PickupEvent{EventBase: et, Player: p1, Item: d["item"]}
+		if props.Has("healing") {
+			if ev.Healing, err = props.Int64("healing"); err != nil {
+				return nil, err
+			}
+		}
+		return ev, nil
+	case say:
+		return &SayEvent{EventBase: et, Player: p1, Message: d["msg"]}, nil
+	case sayTeam:
+		return &SayEvent{EventBase: et, Player: p1, Message: d["msg"], TeamChat: true}, nil
+	case emptyUber:
+		return &EmptyUberEvent{EventBase: et, Player: p1}, nil
+	case medicDeath:
+		healing, err := strconv.ParseInt(d["healing"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse healing: %s", d["healing"])
+		}
+		return &MedicDeathEvent{EventBase: et, Player: p1, Victim: p2, Healing: healing, HadUber: d["uber"] == "1"}, nil
+	case medicDeathEx:
+		pct, err := strconv.ParseInt(d["pct"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse uberpct: %s", d["pct"])
+		}
+		return &MedicDeathExEvent{EventBase: et, Player: p1, UberPct: pct}, nil
+	case lostUberAdv:
+		t, err := strconv.ParseInt(d["advtime"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse time: %s", d["advtime"])
+		}
+		return &LostUberAdvantageEvent{EventBase: et, Player: p1, Time: t}, nil
+	case chargeReady:
+		return &ChargeReadyEvent{EventBase: et, Player: p1}, nil
+	case chargeDeployed:
+		return &ChargeDeployedEvent{EventBase: et, Player: p1, Medigun: parseMedigun(d["medigun"])}, nil
+	case chargeEnded:
+		duration, err := strconv.ParseFloat(d["duration"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse duration: %s", d["duration"])
+		}
+		return &ChargeEndedEvent{EventBase: et, Player: p1, Duration: duration}, nil
+	case healed:
+		healing, err := strconv.ParseInt(d["healing"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse healing: %s", d["healing"])
+		}
+		return &HealedEvent{EventBase: et, Player: p1, Target: p2, Healing: healing}, nil
+	case extinguished:
+		return &ExtinguishedEvent{EventBase: et, Player: p1, Target: p2, Weapon: d["weapon"],
+			AttackerPos: parsePos(d["apos"]), VictimPos: parsePos(d["vpos"])}, nil
+	case objectDeflected:
+		return &DeflectedEvent{EventBase: et, Player: p1, Owner: p2, Weapon: d["weapon"], Position: parsePos(d["pos"])}, nil
+	case playerDeflect:
+		return &PlayerDeflectEvent{EventBase: et, Player: p1, Victim: p2, Weapon: d["weapon"],
+			AttackerPos: parsePos(d["apos"]), VictimPos: parsePos(d["vpos"])}, nil
+	case builtObject:
+		return &BuiltObjectEvent{EventBase: et, Player: p1, Object: d["object"], Position: parsePos(d["Position"])}, nil
+	case carryObject:
+		return &CarryObjectEvent{EventBase: et, Player: p1, Object: d["object"], Position: parsePos(d["Position"])}, nil
+	case dropObject:
+		return &DropObjectEvent{EventBase: et, Player: p1, Object: d["object"], Position: parsePos(d["Position"])}, nil
+	case detonatedObject:
+		return &DetonatedObjectEvent{EventBase: et, Player: p1, Object: d["object"], Position: parsePos(d["Position"])}, nil
+	case killedObject:
+		ev := &KilledObjectEvent{EventBase: et, Player: p1, Owner: p2, Object: d["object"], Weapon: d["weapon"],
+			AttackerPos: parsePos(d["apos"])}
+		if aspos, ok := d["aspos"]; ok {
+			ev.Assist = true
+			ev.AssisterPos = parsePos(aspos)
+		}
+		return ev, nil
+	case firstHealAfterSpawn:
+		ht, err := strconv.ParseFloat(d["healtime"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse healtime: %s", d["healtime"])
+		}
+		return &FirstHealAfterSpawnEvent{EventBase: et, Player: p1,
+			HealTime: time.Duration(ht * float64(time.Second))}, nil
+	case captureBlocked:
+		cp, err := strconv.Atoi(d["cp"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cp: %s", d["cp"])
+		}
+		return &CaptureBlockedEvent{EventBase: et, Player: p1, CP: cp, CPName: d["cpname"],
+			Position: parsePos(d["pos"])}, nil
+	case pointCaptured:
+		return newPointCapturedEvent(et, d, props)
+	case flagEvent:
+		pos, _ := props.Get("position")
+		return &FlagEvent{EventBase: et, Player: p1, Action: d["event"], Position: parsePos(pos)}, nil
+	case wRoundOvertime:
+		return &RoundOvertimeEvent{EventBase: et}, nil
+	case wRoundStart:
+		return &RoundStartEvent{EventBase: et}, nil
+	case wRoundWin:
+		return &RoundWinEvent{EventBase: et, Winner: parseTeam(d["winner"])}, nil
+	case wRoundLen:
+		dur, err := time.ParseDuration(fmt.Sprintf("%ss", d["len"]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse round len: %s", d["len"])
+		}
+		return &RoundLengthEvent{EventBase: et, Length: dur}, nil
+	case wTeamScore, wTeamFinalScore:
+		score, err := strconv.Atoi(d["score"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse score: %s", d["score"])
+		}
+		players, err := strconv.Atoi(d["players"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse players: %s", d["players"])
+		}
+		return &TeamScoreEvent{EventBase: et, Team: parseTeam(d["team"]), Score: score, Players: players,
+			Final: msgType == wTeamFinalScore}, nil
+	case wGameOver:
+		return &GameOverEvent{EventBase: et, Reason: d["reason"]}, nil
+	case wPaused:
+		return &PausedEvent{EventBase: et}, nil
+	case wUnpaused:
+		return &UnpausedEvent{EventBase: et}, nil
+	case logStarted:
+		return &LogStartedEvent{EventBase: et, File: d["file"], Game: d["game"], Version: d["version"]}, nil
+	case loadingMap:
+		return &LoadingMapEvent{EventBase: et, Map: d["map"]}, nil
+	case startedMap:
+		return &StartedMapEvent{EventBase: et, Map: d["map"]}, nil
+	case rcon:
+		return &RconEvent{EventBase: et, Address: d["address"], Command: d["command"]}, nil
+	case serverCvar:
+		if value, found := d["listvalue"]; found {
+			return &ServerCvarEvent{EventBase: et, Name: d["cvar"], Value: value, Listing: true}, nil
+		}
+		return &ServerCvarEvent{EventBase: et, Name: d["cvar"], Value: d["value"]}, nil
+	case tournamentStarted:
+		return &TournamentStartedEvent{EventBase: et}, nil
+	case tournamentTeamName:
+		return &TeamNameEvent{EventBase: et, Team: parseTeam(d["team"]), Name: d["teamname"]}, nil
+	}
+	return nil, ErrUnhandledLine
+}
+
+func newDamageEvent(et EventBase, p1, p2 PlayerRef, d map[string]string, props Properties) (Event, error) {
+	ev := &DamageEvent{EventBase: et, Player: p1, Victim: p2}
+	var err error
+	if ev.Damage, err = props.Int64("damage"); err != nil {
+		return nil, err
+	}
+	if props.Has("realdamage") {
+		// Real damage is counted for back stabs
+		if ev.RealDamage, err = props.Int64("realdamage"); err != nil {
+			return nil, err
+		}
+	}
+	if props.Has("healing") {
+		if ev.Healing, err = props.Int64("healing"); err != nil {
+			return nil, err
+		}
+	}
+	ev.Weapon, _ = props.Get("weapon")
+	ev.Crit, _ = props.Get("crit")
+	headshot, _ := props.Get("headshot")
+	ev.Headshot = headshot == "1"
+	airshot, _ := props.Get("airshot")
+	ev.Airshot = airshot == "1"
+	return ev, nil
+}
+
+func newPointCapturedEvent(et EventBase, d map[string]string, props Properties) (Event, error) {
+	numCappers, err := strconv.Atoi(d["numcappers"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse numcappers: %s", d["numcappers"])
+	}
+	cp, err := strconv.Atoi(d["cp"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cp: %s", d["cp"])
+	}
+	ev := &PointCapturedEvent{EventBase: et, Team: parseTeam(d["team"]), CP: cp, CPName: d["cpname"],
+		NumCappers: numCappers}
+	for _, prop := range props {
+		if strings.HasPrefix(prop.Key, "player") {
+			m := rxPlayer.FindStringSubmatch(prop.Value)
+			if len(m) < 6 {
+				return nil, fmt.Errorf("failed to parse SID from: %s", prop.Value)
+			}
+			ev.Cappers = append(ev.Cappers, newPlayerRef(m[1], m[2], m[3], m[4]))
+		} else if strings.HasPrefix(prop.Key, "position") {
+			ev.Positions = append(ev.Positions, parsePos(prop.Value))
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,94 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestParseEvent(t *testing.T) {
+	ev, err := ParseEvent(`L 07/10/2019 - 23:50:32: "rad<6><[U:1:57823119]><Red>" killed "17<17><[U:1:156985751]><Blue>" with "quake_rl" (attacker_position "-1688 -2242 795") (victim_position "-1666 -2536 690")`)
+	require.NoError(t, err)
+	kill, ok := ev.(*KillEvent)
+	require.True(t, ok)
+	assert.Equal(t, PlayerRef{Name: "rad", PID: 6, SteamID: steamid.SID64(76561198018088847), Team: RED}, kill.Player)
+	assert.Equal(t, "17", kill.Victim.Name)
+	assert.Equal(t, BLU, kill.Victim.Team)
+	assert.Equal(t, "quake_rl", kill.Weapon)
+	assert.Equal(t, Position{-1688, -2242, 795}, kill.AttackerPos)
+	assert.Equal(t, Position{-1666, -2536, 690}, kill.VictimPos)
+	assert.Equal(t, killed, kill.MsgType())
+
+	ev, err = ParseEvent(`L 07/10/2019 - 23:29:54: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "88") (realdamage "32") (weapon "ubersaw") (healing "110")`)
+	require.NoError(t, err)
+	dmg, ok := ev.(*DamageEvent)
+	require.True(t, ok)
+	assert.Equal(t, int64(88), dmg.Damage)
+	assert.Equal(t, int64(32), dmg.RealDamage)
+	assert.Equal(t, int64(110), dmg.Healing)
+	assert.Equal(t, "ubersaw", dmg.Weapon)
+	assert.Equal(t, "z/", dmg.Victim.Name)
+
+	ev, err = ParseEvent(`L 07/11/2019 - 00:11:11: "wonder<7><[U:1:34284979]><Red>" triggered "chargedeployed" (medigun "kritzkrieg")`)
+	require.NoError(t, err)
+	charge, ok := ev.(*ChargeDeployedEvent)
+	require.True(t, ok)
+	assert.Equal(t, kritzkrieg, charge.Medigun)
+
+	ev, err = ParseEvent(`L 07/11/2019 - 00:38:41: Team "Blue" triggered "pointcaptured" (cp "0") (cpname "#koth_viaduct_cap") (numcappers "2") (player1 "AustinN<48><[U:1:167925837]><Blue>") (position1 "99 97 7") (player2 "FTH<54><[U:1:106022087]><Blue>") (position2 "-162 -125 0")`)
+	require.NoError(t, err)
+	cap, ok := ev.(*PointCapturedEvent)
+	require.True(t, ok)
+	assert.Equal(t, BLU, cap.Team)
+	assert.Equal(t, "#koth_viaduct_cap", cap.CPName)
+	assert.Equal(t, 2, cap.NumCappers)
+	assert.Equal(t, []Position{{99, 97, 7}, {-162, -125, 0}}, cap.Positions)
+	require.Len(t, cap.Cappers, 2)
+	assert.Equal(t, "FTH", cap.Cappers[1].Name)
+
+	ev, err = ParseEvent(`L 07/10/2019 - 23:27:02: "z/<14><[U:1:66656848]><Unassigned>" joined team "Blue"`)
+	require.NoError(t, err)
+	assert.Equal(t, BLU, ev.(*JoinedTeamEvent).NewTeam)
+
+	ev, err = ParseEvent(`L 07/10/2019 - 23:40:10: "Graba<3><[U:1:95947321]><Blue>" triggered "lost_uber_advantage" (time "44")`)
+	require.NoError(t, err)
+	adv := ev.(*LostUberAdvantageEvent)
+	assert.Equal(t, int64(44), adv.Time)
+	assert.Equal(t, "23:40:10", adv.CreatedOn.Format("15:04:05"))
+
+	ev, err = ParseEvent(`L 07/11/2019 - 00:11:28: World triggered "Round_Length" (seconds "325.86")`)
+	require.NoError(t, err)
+	assert.Equal(t, 325860*time.Millisecond, ev.(*RoundLengthEvent).Length)
+
+	_, err = ParseEvent(`L 07/11/2019 - 00:50:12: "AMP_T<64><[U:1:163893616]><unknown>" spawned as "undefined"`)
+	assert.Equal(t, ErrSkippedLine, err)
+	_, err = ParseEvent(`L 07/11/2019 - 00:50:12: something else entirely`)
+	assert.Equal(t, ErrUnhandledLine, err)
+}
+
+func TestApplyEvents(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:28:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:28:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+		`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl") (airshot "1")`,
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:29:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:29:00: World triggered "Round_Length" (seconds "60.00")`,
+	}
+	s := NewSummary()
+	for _, l := range lines {
+		s.Apply(l)
+	}
+	rad := s.Players[steamid.SID64(76561198018088847)]
+	require.NotNil(t, rad)
+	assert.Equal(t, int64(90), rad.Damage)
+	assert.Equal(t, 1, rad.AirShots)
+	assert.Equal(t, 1, len(rad.Kills))
+	assert.Equal(t, steamid.SID64(76561198026922576), rad.Kills[0].Victim)
+	assert.Equal(t, 1, s.ScoreRed)
+	assert.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, time.Minute, s.TotalLength())
+}
@@ -0,0 +1,142 @@
+package logstf
+
+import (
+	"strings"
+	"time"
+)
+
+// Flag event actions
+const (
+	FlagPickedUp = "picked up"
+	FlagDropped  = "dropped"
+	FlagCaptured = "captured"
+	FlagDefended = "defended"
+)
+
+type GameMode int
+
+const (
+	ModeUnknown GameMode = iota
+	Mode5CP
+	ModeKOTH
+	ModeAD
+	ModePayload
+	ModeCTF
+	ModeUltiduo
+	ModeBBall
+)
+
+func (m GameMode) String() string {
+	switch m {
+	case Mode5CP:
+		return "5CP"
+	case ModeKOTH:
+		return "KOTH"
+	case ModeAD:
+		return "A/D"
+	case ModePayload:
+		return "Payload"
+	case ModeCTF:
+		return "CTF"
+	case ModeUltiduo:
+		return "Ultiduo"
+	case ModeBBall:
+		return "BBall"
+	default:
+		return "Unknown"
+	}
+}
+
+// modeStats holds the event counts used to detect the game mode when the map name
+// is not conclusive
+type modeStats struct {
+	flagEvents int
+	kothCaps   int
+	caps       map[Team]int
+	roundCaps  int // Captures since the round started
+	midOpens   int // Rounds where the first capture was the middle point, which is the 5CP layout
+}
+
+// detectGameMode works out the mode from the map name first, falling back to the mix of
+// objective events seen when the map prefix is unknown or shared between modes.
+func detectGameMode(mapName string, stats modeStats) GameMode {
+	m := strings.ToLower(mapName)
+	switch {
+	case strings.HasPrefix(m, "ultiduo_") || strings.HasPrefix(m, "koth_ultiduo"):
+		return ModeUltiduo
+	case strings.HasPrefix(m, "ctf_bball") || strings.HasPrefix(m, "ctf_ballin") || strings.HasPrefix(m, "bball_"):
+		return ModeBBall
+	case strings.HasPrefix(m, "koth_"):
+		return ModeKOTH
+	case strings.HasPrefix(m, "pl_") || strings.HasPrefix(m, "plr_"):
+		return ModePayload
+	case strings.HasPrefix(m, "ctf_"):
+		return ModeCTF
+	}
+	switch {
+	case stats.flagEvents > 0:
+		return ModeCTF
+	case stats.kothCaps > 0:
+		return ModeKOTH
+	case strings.HasPrefix(m, "cp_") && stats.midOpens > 0:
+		// One team rolling the other every round still opens at the middle point
+		return Mode5CP
+	case stats.caps[RED] > 0 && stats.caps[BLU] > 0:
+		return Mode5CP
+	case stats.caps[RED] > 0 || stats.caps[BLU] > 0:
+		// Only one side ever capping is attack/defend
+		return ModeAD
+	}
+	if strings.HasPrefix(m, "cp_") {
+		return Mode5CP
+	}
+	return ModeUnknown
+}
+
+func (s *LogSummary) updateGameMode() {
+	s.GameMode = detectGameMode(s.Map, s.modeStats)
+}
+
+func (s *LogSummary) setMap(mapName string) {
+	s.Map = mapName
+	s.updateGameMode()
+}
+
+func (s *LogSummary) countCapture(team Team, cp int, cpName string) {
+	s.modeStats.caps[team]++
+	if s.modeStats.roundCaps == 0 && cp == midPoint5CP {
+		s.modeStats.midOpens++
+	}
+	s.modeStats.roundCaps++
+	if strings.Contains(strings.ToLower(cpName), "koth") {
+		s.modeStats.kothCaps++
+	}
+	s.updateGameMode()
+}
+
+func (s *LogSummary) flagEvent(player *Player, team Team, action string, ts time.Time) {
+	s.modeStats.flagEvents++
+	s.updateGameMode()
+	switch action {
+	case FlagPickedUp:
+		player.IntelPickups++
+		player.carryStart = ts
+	case FlagDropped:
+		player.IntelDrops++
+		s.endIntelCarry(player, ts)
+	case FlagCaptured:
+		player.IntelCaptures++
+		s.getTeamSummary(team).IntelCaps++
+		s.endIntelCarry(player, ts)
+	case FlagDefended:
+		player.IntelDefenses++
+	}
+}
+
+func (s *LogSummary) endIntelCarry(player *Player, ts time.Time) {
+	if player.carryStart.IsZero() {
+		return
+	}
+	player.IntelCarryTime += ts.Sub(player.carryStart)
+	player.carryStart = time.Time{}
+}
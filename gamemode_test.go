@@ -0,0 +1,83 @@
+package logstf
+
+import (
+	"fmt"
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDetectGameMode(t *testing.T) {
+	type modeTest struct {
+		Map      string
+		Stats    modeStats
+		Expected GameMode
+	}
+	tests := []modeTest{
+		{"cp_process_final", modeStats{}, Mode5CP},
+		{"koth_product_rcx", modeStats{}, ModeKOTH},
+		{"koth_ultiduo_r_b7", modeStats{}, ModeUltiduo},
+		{"ultiduo_baloo", modeStats{}, ModeUltiduo},
+		{"ctf_ballin_sky", modeStats{}, ModeBBall},
+		{"ctf_turbine_pro", modeStats{}, ModeCTF},
+		{"pl_upward", modeStats{}, ModePayload},
+		{"cp_steel", modeStats{caps: map[Team]int{BLU: 4}}, ModeAD},
+		{"cp_granary_pro", modeStats{caps: map[Team]int{BLU: 4, RED: 2}}, Mode5CP},
+		{"cp_granary_pro", modeStats{caps: map[Team]int{RED: 3}, midOpens: 1}, Mode5CP},
+		{"cp_gravelpit", modeStats{caps: map[Team]int{BLU: 3}}, ModeAD},
+		{"", modeStats{flagEvents: 3}, ModeCTF},
+		{"", modeStats{kothCaps: 3, caps: map[Team]int{BLU: 2, RED: 1}}, ModeKOTH},
+		{"", modeStats{}, ModeUnknown},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.Expected, detectGameMode(test.Map, test.Stats), test.Map)
+	}
+	assert.Equal(t, "A/D", ModeAD.String())
+}
+
+func TestGameModeStomp(t *testing.T) {
+	var lines []string
+	for _, start := range []string{"23:00", "23:05"} {
+		lines = append(lines, `L 07/10/2019 - `+start+`:00: World triggered "Round_Start"`)
+		for i, name := range []string{"#Badlands_cap_cp3", "#Badlands_cap_cp4", "#Badlands_cap_cp5"} {
+			lines = append(lines, fmt.Sprintf(`L 07/10/2019 - %s:%02d: Team "Red" triggered "pointcaptured" (cp "%d") (cpname "%s") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "1 2 3")`,
+				start, 10*(i+1), i+2, name))
+		}
+		lines = append(lines, `L 07/10/2019 - `+start+`:40: World triggered "Round_Win" (winner "Red")`)
+	}
+	s := NewSummary()
+	s.setMap("cp_badlands")
+	for _, l := range lines {
+		require.NoError(t, s.Apply(l))
+	}
+	assert.Equal(t, Mode5CP, s.GameMode)
+	assert.Equal(t, 2, s.Teams[RED].MidFights)
+	assert.Equal(t, 10*time.Second, s.AvgMidFightTime(RED))
+	assert.Equal(t, 2, s.Teams[BLU].LastLosses)
+}
+
+func TestIntelStats(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:28:10: "rad<6><[U:1:57823119]><Red>" triggered "flagevent" (event "picked up") (position "1 2 3")`,
+		`L 07/10/2019 - 23:28:20: "rad<6><[U:1:57823119]><Red>" triggered "flagevent" (event "dropped") (position "1 2 3")`,
+		`L 07/10/2019 - 23:28:25: "z/<14><[U:1:66656848]><Blue>" triggered "flagevent" (event "defended") (position "1 2 3")`,
+		`L 07/10/2019 - 23:28:30: "rad<6><[U:1:57823119]><Red>" triggered "flagevent" (event "picked up") (position "1 2 3")`,
+		`L 07/10/2019 - 23:29:00: "rad<6><[U:1:57823119]><Red>" triggered "flagevent" (event "captured") (position "1 2 3")`,
+	}
+	s := NewSummary()
+	for _, l := range lines {
+		require.NoError(t, s.Apply(l))
+	}
+	assert.Equal(t, ModeCTF, s.GameMode)
+	rad := s.Players[steamid.SID64(76561198018088847)]
+	require.NotNil(t, rad)
+	assert.Equal(t, 2, rad.IntelPickups)
+	assert.Equal(t, 1, rad.IntelDrops)
+	assert.Equal(t, 1, rad.IntelCaptures)
+	assert.Equal(t, 40*time.Second, rad.IntelCarryTime)
+	assert.Equal(t, 1, s.Teams[RED].IntelCaps)
+	assert.Equal(t, 1, s.Players[steamid.SID64(76561198026922576)].IntelDefenses)
+}
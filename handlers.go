@@ -1,7 +1,6 @@
 package logstf
 
 import (
-	log "github.com/sirupsen/logrus"
 	"time"
 )
 
@@ -9,10 +8,18 @@ func (s *LogSummary) joinTeam(player *Player, team Team) {
 	if team != SPEC {
 		player.Team = team
 	}
+	s.teamChanged(player, team, s.now)
 }
 
 func (s *LogSummary) spawnedAs(player *Player, cls PlayerClass) {
+	if cls != player.CurrentClass {
+		// Close off the time played on the previous class
+		s.settle(player, s.now)
+	}
 	player.AddClass(cls)
+	if cls == medic {
+		s.medicSpawned(player, s.now)
+	}
 }
 
 func (s *LogSummary) headShot(player1 *Player, pos1 Position, weapon string, player2 *Player, pos2 Position, dt time.Time) {
@@ -26,42 +33,61 @@ func (s *LogSummary) backStab(player1 *Player, pos1 Position, weapon string, pla
 }
 
 func (s *LogSummary) killed(player1 *Player, pos1 Position, weapon string, player2 *Player, pos2 Position, dt time.Time) {
-	if !s.isRoundStarted() {
-		return
-	}
-	player1.Kills = append(player1.Kills, Kill{pos1, pos2, player2.SteamId, dt})
+	player1.Kills = append(player1.Kills, Kill{pos1, pos2, player2.SteamId, dt, weapon, s.currentRound})
 	s.getTeamSummary(player1.Team).Kills++
-	if player1.Team == RED {
-		s.currentRoundSummary.KillsRed++
-	} else if player1.Team == BLU {
-		s.currentRoundSummary.KillsBlu++
+	// There is no round during warmup
+	if r := s.currentRoundSummary; r != nil && player1.Team == RED {
+		r.KillsRed++
+	} else if r != nil && player1.Team == BLU {
+		r.KillsBlu++
+	}
+	player2.Deaths = append(player2.Deaths, Kill{pos1, pos2, player1.SteamId, dt, weapon, s.currentRound})
+	if w := player1.weapon(weapon); w != nil {
+		w.Kills++
+	}
+	if isBuildingWeapon(weapon) {
+		player1.Buildings.Kills[weapon]++
+	}
+	if isReflectWeapon(weapon) {
+		player1.ReflectKills[weapon]++
+	}
+	s.classKill(player1, player2)
+	s.firstBlood(player1, player2)
+	if cs := player1.classStats(); cs != nil {
+		cs.Kills++
+	}
+	if cs := player2.classStats(); cs != nil {
+		cs.Deaths++
 	}
-	player2.Deaths = append(player2.Deaths, Kill{pos1, pos2, player1.SteamId, dt})
 }
 
-func (s *LogSummary) suicide(player1 *Player, pos1 Position, dt time.Time) {
-	if !s.isRoundStarted() {
-		return
+func (s *LogSummary) suicide(player1 *Player, pos1 Position, weapon string, dt time.Time) {
+	player1.Deaths = append(player1.Deaths, Kill{pos1, pos1, player1.SteamId, dt, weapon, s.currentRound})
+	if cs := player1.classStats(); cs != nil {
+		cs.Deaths++
 	}
-	player1.Deaths = append(player1.Deaths, Kill{pos1, pos1, player1.SteamId, dt})
 }
 
 func (s *LogSummary) shotFired(player *Player, weapon string) {
-	if !s.isRoundStarted() {
-		return
-	}
 	player.ShotsFired++
+	if w := player.weapon(weapon); w != nil {
+		w.Shots++
+	}
 }
 
 func (s *LogSummary) shotHit(player *Player, weapon string) {
-	if !s.isRoundStarted() {
-		return
-	}
 	player.ShotsHit++
+	if w := player.weapon(weapon); w != nil {
+		w.Hits++
+	}
 }
 
 func (s *LogSummary) assist(player1 *Player, assisterPos Position, player2 *Player, attackerPos Position) {
 	player1.Assists++
+	if cs := player1.classStats(); cs != nil {
+		cs.Assist++
+	}
+	s.classAssist(player1, player2)
 }
 
 func (s *LogSummary) airShot(player1 *Player) {
@@ -77,21 +103,29 @@ func (s *LogSummary) captureBlocked(player1 *Player) {
 }
 
 func (s *LogSummary) damage(player1 *Player, amount int64, weapon string, player2 *Player) {
-	if !s.isRoundStarted() {
-		return
-	}
 	// Overall player1 damage
 	player1.Damage += amount
+	if cs := player1.classStats(); cs != nil {
+		cs.Damage += int(amount)
+	}
+	if w := player1.weapon(weapon); w != nil {
+		w.addDamage(amount)
+	}
+	if isBuildingWeapon(weapon) {
+		player1.Buildings.Damage[weapon] += amount
+	}
 
 	// Overall team damage
 	s.getTeamSummary(player1.Team).Damage += amount
 
-	// Current round damage
-	switch player1.Team {
-	case RED:
-		s.currentRoundSummary.DamageRed += amount
-	case BLU:
-		s.currentRoundSummary.DamageBlu += amount
+	// Current round damage, there is no round during warmup
+	if r := s.currentRoundSummary; r != nil {
+		switch player1.Team {
+		case RED:
+			r.DamageRed += amount
+		case BLU:
+			r.DamageBlu += amount
+		}
 	}
 	if player2 != nil {
 		// Not present on older logs?
@@ -106,7 +140,8 @@ func (s *LogSummary) selfHealed(player *Player, amount int64) {
 
 func (s *LogSummary) healed(player1 *Player, player2 *Player, amount int64) {
 	if player1.HealingSum == nil {
-		log.Warnf("Healing sum nil")
+		// Medics already spawned when the log started
+		player1.HealingSum = NewHealingSummary()
 	}
 	player1.HealingSum.Healing += amount
 	player1.HealingSum.Targets[player2] += amount
@@ -114,24 +149,38 @@ func (s *LogSummary) healed(player1 *Player, player2 *Player, amount int64) {
 
 func (s *LogSummary) wRoundStart(dt time.Time) {
 	s.roundStarted = true
+	s.setPhase(PhaseActive)
+	s.modeStats.roundCaps = 0
 	s.roundStartTime = dt
+	s.currentRound++
+	if s.matchStart.IsZero() {
+		s.matchStart = dt
+	}
 	s.currentRoundSummary = &RoundSummary{
 		MidFight: SPEC,
 	}
+	s.settleAll(dt)
 }
 
 func (s *LogSummary) wRoundLen(t time.Duration, trt time.Duration) {
+	if s.currentRoundSummary == nil {
+		return
+	}
 	s.currentRoundSummary.Length = t
 	s.currentRoundSummary.LengthRt = trt
-	s.currentRound++
 }
 
 func (s *LogSummary) wRoundWin(dt time.Time, winner Team) {
 	s.roundStarted = false
-	if s.currentRoundSummary != nil {
-		s.Rounds = append(s.Rounds, s.currentRoundSummary)
+	s.setPhase(PhaseHumiliation)
+	s.settleAll(dt)
+	if s.currentRoundSummary == nil {
+		return
 	}
-	s.currentRoundSummary.LengthRt += dt.Sub(s.roundStartTime)
+	s.currentRoundSummary.Winner = winner
+	s.endLastHold(winner)
+	s.Rounds = append(s.Rounds, s.currentRoundSummary)
+	s.currentRoundSummary.LengthRt = s.gameDuration(s.roundStartTime, dt)
 	if winner == RED {
 		s.ScoreRed++
 	} else if winner == BLU {
@@ -139,10 +188,16 @@ func (s *LogSummary) wRoundWin(dt time.Time, winner Team) {
 	}
 }
 
+// TotalLength returns the game time of all rounds, excluding pauses. The length reported by
+// the server is used when the round times are unknown, eg. from the api.
 func (s *LogSummary) TotalLength() time.Duration {
 	var t time.Duration
 	for _, r := range s.Rounds {
-		t += r.Length
+		if r.LengthRt > 0 {
+			t += r.LengthRt
+		} else {
+			t += r.Length
+		}
 	}
 	return t
 }
@@ -164,8 +219,10 @@ func (s *LogSummary) chargeEnded(player *Player, duration float64) {
 	player.HealingSum.ChargeLengths = append(player.HealingSum.ChargeLengths, duration)
 }
 
-func (s *LogSummary) chargeDeployed(player *Player, medigun Medigun) {
+func (s *LogSummary) chargeDeployed(player *Player, medigun Medigun, ts time.Time) {
 	player.HealingSum.Charges[medigun]++
+	s.addRoundEvent(RoundEvent{Type: RoundEventCharge, Team: player.Team, SteamId: player.SteamId, Medigun: medigun})
+	player.HealingSum.chargeUsed(ts)
 }
 
 func (s *LogSummary) chargeDropped(player *Player) {
@@ -181,6 +238,7 @@ func (s *LogSummary) chargeAlmostDropped(player *Player) {
 
 func (s *LogSummary) emptyUber(player *Player, ts time.Time) {
 	player.HealingSum.lastEmptyUber = ts
+	player.HealingSum.buildStart = ts
 }
 
 func (s *LogSummary) say(player *Player, ts time.Time, message string, teamChat bool) {
@@ -191,23 +249,39 @@ func (s *LogSummary) say(player *Player, ts time.Time, message string, teamChat
 		Timestamp: ts,
 	})
 }
+
+// pause starts a pause, the server can log Game_Paused more than once for a single pause so
+// only the first counts
 func (s *LogSummary) pause(ts time.Time) {
-	s.lastPause = ts
+	if s.paused {
+		return
+	}
+	s.addRoundEvent(RoundEvent{Type: RoundEventPause})
+	s.Pauses = append(s.Pauses, Pause{Start: ts})
 	s.paused = true
+	s.pausePhase()
+	s.settleAll(ts)
 }
 
 func (s *LogSummary) unpause(ts time.Time) {
 	// Dont count the duplicate pause/unpause log lines
-	if s.paused {
-		s.lastPauseDuration = ts.Sub(s.lastPause)
-		s.paused = false
+	if !s.paused {
+		return
 	}
+	s.Pauses[len(s.Pauses)-1].End = ts
+	s.paused = false
+	s.unpausePhase()
+	s.settleAll(ts)
+	s.addRoundEvent(RoundEvent{Type: RoundEventUnpause})
 }
 
 func (s *LogSummary) firstHealTime(player *Player, d time.Duration) {
 	player.HealingSum.timesUntilHeal = append(player.HealingSum.timesUntilHeal, d)
 }
 
-func (s *LogSummary) lostAdvantage(player *Player) {
+func (s *LogSummary) lostAdvantage(player *Player, seconds int64) {
 	player.HealingSum.MajorAdvantagesLost++
+	if int(seconds) > player.HealingSum.BiggestAdvantageLost {
+		player.HealingSum.BiggestAdvantageLost = int(seconds)
+	}
 }
@@ -0,0 +1,68 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func applyLines(t *testing.T, lines []string) *LogSummary {
+	s := NewSummary()
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines, "\n"))))
+	return s
+}
+
+// classTestLines has a soldier switching to pyro and dying to a scout with a medic assisting
+var classTestLines = []string{
+	`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+	`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+	`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+	`L 07/10/2019 - 23:00:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+	`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "150") (weapon "quake_rl")`,
+	`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:02:00: "rad<6><[U:1:57823119]><Red>" changed role to "Pyro"`,
+	`L 07/10/2019 - 23:02:00: "rad<6><[U:1:57823119]><Red>" spawned as "Pyro"`,
+	`L 07/10/2019 - 23:03:00: "z/<14><[U:1:66656848]><Blue>" triggered "damage" against "rad<6><[U:1:57823119]><Red>" (damage "50") (weapon "scattergun")`,
+	`L 07/10/2019 - 23:03:00: "z/<14><[U:1:66656848]><Blue>" killed "rad<6><[U:1:57823119]><Red>" with "scattergun" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:03:00: "Graba<3><[U:1:95947321]><Blue>" triggered "kill assist" against "rad<6><[U:1:57823119]><Red>" (assister_position "1 2 3") (attacker_position "4 5 6") (victim_position "7 8 9")`,
+	`L 07/10/2019 - 23:05:00: World triggered "Round_Win" (winner "Blue")`,
+	`L 07/10/2019 - 23:05:00: World triggered "Round_Length" (seconds "300.00")`,
+}
+
+func TestClassStats(t *testing.T) {
+	s := applyLines(t, classTestLines)
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	require.Equal(t, 2, len(rad.Classes))
+	assert.Equal(t, classStats{Kills: 1, Damage: 150, TotalTime: 2 * time.Minute}, *rad.Classes[soldier])
+	assert.Equal(t, classStats{Deaths: 1, TotalTime: 3 * time.Minute}, *rad.Classes[pyro])
+
+	scout := s.Players[76561198026922576]
+	assert.Equal(t, classStats{Kills: 1, Deaths: 1, Damage: 50, TotalTime: 5 * time.Minute}, *scout.Classes[scout.CurrentClass])
+
+	med := s.Players[76561198056213049]
+	assert.Equal(t, 1, med.Classes[medic].Assist)
+}
+
+func TestClassStatsChangedRole(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 22:59:00: Log file started (file "logs/L0710000.log") (game "/home/tf2/tf") (version "5409153")`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:01:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:02:00: "rad<6><[U:1:57823119]><Red>" changed role to "Pyro"`,
+		`L 07/10/2019 - 23:03:00: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "150") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:03:00: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:04:00: "rad<6><[U:1:57823119]><Red>" committed suicide with "world" (attacker_position "1 2 3")`,
+		`L 07/10/2019 - 23:04:10: "rad<6><[U:1:57823119]><Red>" spawned as "Pyro"`,
+		`L 07/10/2019 - 23:06:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:07:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+	})
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	assert.Equal(t, soldier, rad.CurrentClass)
+	assert.Equal(t, classStats{Kills: 1, Deaths: 1, Damage: 150, TotalTime: 3*time.Minute + 10*time.Second}, *rad.Classes[soldier])
+	assert.Equal(t, classStats{TotalTime: time.Minute + 50*time.Second}, *rad.Classes[pyro])
+}
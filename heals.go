@@ -0,0 +1,79 @@
+package logstf
+
+import (
+	"strings"
+)
+
+// HealSource is where healing received came from
+type HealSource int
+
+const (
+	HealMedic HealSource = iota
+	HealDispenser
+	HealFood // Sandvich and the other lunchbox items
+	HealPack
+	HealWeapon // Lifesteal from the healing property of damage, eg. black box
+	HealOther
+)
+
+func (h HealSource) String() string {
+	switch h {
+	case HealMedic:
+		return "medic"
+	case HealDispenser:
+		return "dispenser"
+	case HealFood:
+		return "food"
+	case HealPack:
+		return "pack"
+	case HealWeapon:
+		return "weapon"
+	default:
+		return "other"
+	}
+}
+
+// foodItems are the lunchbox items that can be picked up off the ground
+var foodItems = []string{"sandvich", "steak", "dalokohs", "fishcake", "banana", "chocolate"}
+
+func isFoodItem(item string) bool {
+	item = strings.ToLower(item)
+	for _, food := range foodItems {
+		if strings.Contains(item, food) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *LogSummary) healReceived(player *Player, src HealSource, amount int64) {
+	player.HealsBySource[src] += amount
+}
+
+// healedBy records healing from another player. The source is decided by the healers class,
+// engineers heal through their dispenser and heavies through the sandvich they dropped.
+func (s *LogSummary) healedBy(healer *Player, target *Player, amount int64) {
+	target.HealsReceived += amount
+	switch healer.CurrentClass {
+	case medic:
+		target.HealsByMedic[healer.SteamId] += amount
+		s.healReceived(target, HealMedic, amount)
+	case engineer:
+		s.healReceived(target, HealDispenser, amount)
+	case heavy:
+		s.healReceived(target, HealFood, amount)
+	default:
+		s.healReceived(target, HealOther, amount)
+	}
+}
+
+func (s *LogSummary) itemHealed(player *Player, item string, amount int64) {
+	switch {
+	case isFoodItem(item):
+		s.healReceived(player, HealFood, amount)
+	case strings.HasPrefix(item, "medkit"):
+		s.healReceived(player, HealPack, amount)
+	default:
+		s.healReceived(player, HealOther, amount)
+	}
+}
@@ -0,0 +1,32 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHealsReceived(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:00:00: "Kwq<9><[U:1:96748980]><Blue>" spawned as "Engineer"`,
+		`L 07/10/2019 - 23:00:00: "wonder<7><[U:1:34284979]><Blue>" spawned as "HeavyWeapons"`,
+		`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:01: "Graba<3><[U:1:95947321]><Blue>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "100")`,
+		`L 07/10/2019 - 23:00:02: "Kwq<9><[U:1:96748980]><Blue>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "40")`,
+		`L 07/10/2019 - 23:00:03: "wonder<7><[U:1:34284979]><Blue>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "75")`,
+		`L 07/10/2019 - 23:00:04: "z/<14><[U:1:66656848]><Blue>" picked up item "medkit_small" (healing "20")`,
+		`L 07/10/2019 - 23:00:05: "z/<14><[U:1:66656848]><Blue>" triggered "damage" against "rad<6><[U:1:57823119]><Red>" (damage "90") (weapon "blackbox") (healing "15")`,
+	})
+	p := s.Players[76561198026922576]
+	require.NotNil(t, p)
+	assert.Equal(t, int64(215), p.HealsReceived)
+	assert.Equal(t, map[HealSource]int64{HealMedic: 100, HealDispenser: 40, HealFood: 75, HealPack: 20, HealWeapon: 15}, p.HealsBySource)
+	assert.Equal(t, "dispenser", HealDispenser.String())
+	assert.Equal(t, map[steamid.SID64]int64{76561198056213049: 100}, p.HealsByMedic)
+	assert.Equal(t, int64(100), s.Players[76561198056213049].HealingSum.Healing)
+	assert.True(t, isFoodItem("sandvich"))
+	assert.False(t, isFoodItem("medkit_medium"))
+}
@@ -0,0 +1,113 @@
+package logstf
+
+import (
+	"errors"
+	"github.com/leighmacdonald/steamid"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// sid64Base is the SID64 of the first individual account, used to convert the account
+// id of a SID3 without going through the string conversions of the steamid package
+const sid64Base = 76561197960265728
+
+// ErrInvalidPlayer is returned when a player token is not in the name<pid><sid><team> format
+var ErrInvalidPlayer = errors.New("invalid player")
+
+// PlayerRef is a player as referenced within a single log line. Bots do not have a steam id
+// so they are given a stable id derived from their name, see BotSteamID. The server console
+// is not a player and is left with an invalid SteamID.
+type PlayerRef struct {
+	Name      string
+	PID       int
+	SteamID   steamid.SID64
+	Team      Team
+	IsBot     bool
+	IsConsole bool
+}
+
+// BotSteamID returns the id used to track a bot by name. These ids are far below the range
+// of real accounts so they can never collide with a player.
+func BotSteamID(name string) steamid.SID64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return steamid.SID64(uint64(h.Sum32()) + 1)
+}
+
+// ParsePlayerRef parses the contents of a player token such as `name<3><[U:1:1234]><Red>`.
+// The token is read from the right so that names containing <, > or quotes are kept intact.
+// SteamIDs may be in Steam3, legacy STEAM_X:Y:Z or SID64 format as well as BOT or Console.
+func ParsePlayerRef(tok string) (PlayerRef, error) {
+	var parts [3]string
+	for i := 2; i >= 0; i-- {
+		if len(tok) == 0 || tok[len(tok)-1] != '>' {
+			return PlayerRef{}, ErrInvalidPlayer
+		}
+		start := strings.LastIndexByte(tok, '<')
+		if start < 0 {
+			return PlayerRef{}, ErrInvalidPlayer
+		}
+		parts[i] = tok[start+1 : len(tok)-1]
+		tok = tok[:start]
+	}
+	pid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return PlayerRef{}, ErrInvalidPlayer
+	}
+	switch parts[2] {
+	case "", "Unassigned", "Red", "Blue", "Spectator", "Console":
+	default:
+		return PlayerRef{}, ErrInvalidPlayer
+	}
+	ref := PlayerRef{Name: tok, PID: pid, Team: parseTeam(parts[2])}
+	switch parts[1] {
+	case "BOT":
+		ref.IsBot = true
+		ref.SteamID = BotSteamID(tok)
+	case "Console":
+		ref.IsConsole = true
+	default:
+		sid, ok := parseSteamID(parts[1])
+		if !ok {
+			return PlayerRef{}, ErrInvalidPlayer
+		}
+		ref.SteamID = sid
+	}
+	return ref, nil
+}
+
+// parseSteamID converts the Steam3, legacy STEAM_X:Y:Z and SID64 formats without allocating
+func parseSteamID(sid string) (steamid.SID64, bool) {
+	switch {
+	case strings.HasPrefix(sid, "[U:") && strings.HasSuffix(sid, "]"):
+		i := strings.LastIndexByte(sid, ':')
+		id32, err := strconv.ParseUint(sid[i+1:len(sid)-1], 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return steamid.SID64(sid64Base + id32), true
+	case strings.HasPrefix(sid, "STEAM_"):
+		// STEAM_X:Y:Z where Y is the low bit and Z the rest of the account id
+		rest := sid[len("STEAM_"):]
+		i := strings.IndexByte(rest, ':')
+		j := strings.LastIndexByte(rest, ':')
+		if i < 0 || j <= i {
+			return 0, false
+		}
+		y, err := strconv.ParseUint(rest[i+1:j], 10, 1)
+		if err != nil {
+			return 0, false
+		}
+		z, err := strconv.ParseUint(rest[j+1:], 10, 31)
+		if err != nil {
+			return 0, false
+		}
+		return steamid.SID64(sid64Base + z*2 + y), true
+	}
+	id64, err := strconv.ParseUint(sid, 10, 64)
+	if err != nil || id64 < sid64Base {
+		return 0, false
+	}
+	return steamid.SID64(id64), true
+}
@@ -0,0 +1,58 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParsePlayerRef(t *testing.T) {
+	type refTest struct {
+		Token    string
+		Expected PlayerRef
+	}
+	tests := []refTest{
+		{`rad<6><[U:1:57823119]><Red>`, PlayerRef{Name: "rad", PID: 6, SteamID: 76561198018088847, Team: RED}},
+		{`funk. Bubi<382><STEAM_0:1:22649331><>`, PlayerRef{Name: "funk. Bubi", PID: 382, SteamID: 76561198005564391}},
+		{`a<1><b><7><[U:1:57823119]><Blue>`, PlayerRef{Name: "a<1><b>", PID: 7, SteamID: 76561198018088847, Team: BLU}},
+		{`"quoted" >name<<9><76561198018088847><Spectator>`, PlayerRef{Name: `"quoted" >name<`, PID: 9, SteamID: 76561198018088847}},
+		{`Bot01<3><BOT><Red>`, PlayerRef{Name: "Bot01", PID: 3, SteamID: BotSteamID("Bot01"), Team: RED, IsBot: true}},
+		{`Console<0><Console><Console>`, PlayerRef{Name: "Console", IsConsole: true}},
+	}
+	for _, test := range tests {
+		ref, err := ParsePlayerRef(test.Token)
+		require.NoError(t, err, test.Token)
+		assert.Equal(t, test.Expected, ref, test.Token)
+	}
+	assert.Equal(t, steamid.SIDToSID64("STEAM_0:1:22649331"), tests[1].Expected.SteamID)
+	assert.NotEqual(t, BotSteamID("Bot01"), BotSteamID("Bot02"))
+	assert.True(t, BotSteamID("Bot01") < sid64Base)
+
+	for _, bad := range []string{``, `name`, `name<x><[U:1:1]><Red>`, `name<1><[U:1:1]><Purple>`, `name<1><garbage><Red>`} {
+		_, err := ParsePlayerRef(bad)
+		assert.Equal(t, ErrInvalidPlayer, err, bad)
+	}
+}
+
+func TestHostilePlayerNames(t *testing.T) {
+	s := NewSummary()
+	lines := []string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:28:00: "a<1><b>" x<6><[U:1:57823119]><Red>" killed "Bot01<3><BOT><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:28:01: "Console<0><Console><Console>" say "hello"`,
+	}
+	for _, l := range lines {
+		assert.NoError(t, s.Apply(l))
+	}
+	p := s.Players[steamid.SID64(76561198018088847)]
+	require.NotNil(t, p)
+	assert.Equal(t, `a<1><b>" x`, p.Name)
+	assert.Equal(t, 1, len(p.Kills))
+	bot := s.Players[BotSteamID("Bot01")]
+	require.NotNil(t, bot)
+	assert.True(t, bot.IsBot)
+	assert.Equal(t, 1, len(bot.Deaths))
+	assert.Equal(t, 0, s.Report.UnhandledCount())
+	assert.Equal(t, 2, len(s.Players))
+}
@@ -0,0 +1,637 @@
+package logstf
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLexProps is the number of properties stored without allocating. Point captures
+// with a full highlander team are the longest lines we see.
+const maxLexProps = 24
+
+// lexProps holds the `(key "value")` suffix properties of a line along with any
+// failures converting the optional ones
+type lexProps struct {
+	buf   [maxLexProps]Property
+	items []Property
+	errs  FieldErrors
+}
+
+func (p *lexProps) get(key string) (string, bool) {
+	for _, prop := range p.items {
+		if prop.Key == key {
+			return prop.Value, true
+		}
+	}
+	return "", false
+}
+
+func (p *lexProps) int64(key string) (int64, error) {
+	v, _ := p.get(key)
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &FieldError{Field: key, Value: v, Err: err}
+	}
+	return i, nil
+}
+
+func (p *lexProps) float64(key string) (float64, error) {
+	v, _ := p.get(key)
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, &FieldError{Field: key, Value: v, Err: err}
+	}
+	return f, nil
+}
+
+func (p *lexProps) pos(key string) Position {
+	v, found := p.get(key)
+	if !found {
+		return Position{}
+	}
+	return p.parsePos(key, v)
+}
+
+// parsePos converts the position, recording a failure as a non fatal field error
+func (p *lexProps) parsePos(key string, v string) Position {
+	pos, err := parsePosition(v)
+	if err != nil {
+		p.errs = append(p.errs, &FieldError{Field: key, Value: v, Err: err})
+	}
+	return pos
+}
+
+// lexer is a cursor over a single log line. It walks the line once from left to right and
+// dispatches on the verb instead of trying each regex in turn like parseLine.
+type lexer struct {
+	line  string
+	pos   int
+	opts  ParseOptions
+	props lexProps
+}
+
+func (l *lexer) rest() string {
+	return l.line[l.pos:]
+}
+
+func (l *lexer) accept(prefix string) bool {
+	if strings.HasPrefix(l.line[l.pos:], prefix) {
+		l.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.line) && l.line[l.pos] == ' ' {
+		l.pos++
+	}
+}
+
+// quoted reads a "value" returning the value without quotes
+func (l *lexer) quoted() (string, bool) {
+	if !l.accept(`"`) {
+		return "", false
+	}
+	end := strings.IndexByte(l.line[l.pos:], '"')
+	if end < 0 {
+		return "", false
+	}
+	v := l.line[l.pos : l.pos+end]
+	l.pos += end + 1
+	return v, true
+}
+
+// player reads a "name<pid><sid><team>" player token. Names may contain `>"` so each
+// candidate end of the token is tried until one parses and is followed by a space or the
+// end of the line.
+func (l *lexer) player() (PlayerRef, bool) {
+	if !l.accept(`"`) {
+		return PlayerRef{}, false
+	}
+	offset := 0
+	for {
+		end := strings.Index(l.line[l.pos+offset:], `>"`)
+		if end < 0 {
+			return PlayerRef{}, false
+		}
+		end += offset
+		next := l.pos + end + 2
+		if next == len(l.line) || l.line[next] == ' ' {
+			if ref, err := ParsePlayerRef(l.line[l.pos : l.pos+end+1]); err == nil {
+				l.pos = next
+				return ref, true
+			}
+		}
+		offset = end + 1
+	}
+}
+
+// properties reads all of the remaining `(key "value")` pairs of the line
+func (l *lexer) properties() bool {
+	l.props.items = l.props.buf[:0]
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.line) {
+			return true
+		}
+		key, value, rest, ok := scanProperty(l.rest())
+		if !ok {
+			return false
+		}
+		l.props.items = append(l.props.items, Property{Key: key, Value: value})
+		l.pos = len(l.line) - len(rest)
+	}
+}
+
+// lexers are reused between lines since the property buffer makes them fairly large
+var lexers = sync.Pool{New: func() interface{} { return &lexer{} }}
+
+// lexLine parses a line into its event in a single pass
+func lexLine(line string, opts ParseOptions) (Event, error) {
+	l := lexers.Get().(*lexer)
+	l.line, l.pos, l.opts, l.props.items, l.props.errs = line, 0, opts, l.props.buf[:0], nil
+	ev, err := lexEvent(l)
+	if ev != nil && len(l.props.items) > 0 {
+		props := make(Properties, len(l.props.items))
+		copy(props, l.props.items)
+		ev.eventBase().Properties = props
+	}
+	if err == nil && len(l.props.errs) > 0 {
+		err = l.props.errs
+	}
+	// Dont hold on to the line via the property buffer
+	for i := range l.props.items {
+		l.props.items[i] = Property{}
+	}
+	l.line, l.opts = "", ParseOptions{}
+	lexers.Put(l)
+	if err == ErrUnhandledLine && strings.HasSuffix(line, `"undefined"`) {
+		return nil, ErrSkippedLine
+	}
+	return ev, err
+}
+
+func lexEvent(l *lexer) (Event, error) {
+	// L 07/10/2019 - 23:28:01: <body>
+	if !l.accept("L ") {
+		return nil, ErrUnhandledLine
+	}
+	sep := strings.Index(l.rest(), " - ")
+	if sep < 0 {
+		return nil, ErrUnhandledLine
+	}
+	dateStr := l.rest()[:sep]
+	l.pos += sep + 3
+	sep = strings.Index(l.rest(), ": ")
+	if sep < 0 {
+		return nil, ErrUnhandledLine
+	}
+	timeStr := l.rest()[:sep]
+	l.pos += sep + 2
+	dt, err := parseLogTime(dateStr, timeStr, l.opts)
+	if err != nil {
+		return nil, &FieldError{Field: "date", Value: dateStr + " - " + timeStr, Err: err}
+	}
+	et := EventBase{CreatedOn: dt}
+	switch {
+	case l.accept(`World triggered "`):
+		return lexWorld(l, et)
+	case l.accept(`Team "`):
+		return lexTeam(l, et)
+	case l.accept("Log file started"):
+		if !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		ev := &LogStartedEvent{EventBase: et}
+		ev.File, _ = l.props.get("file")
+		ev.Game, _ = l.props.get("game")
+		ev.Version, _ = l.props.get("version")
+		return ev, nil
+	case l.accept("Loading map "):
+		mapName, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return &LoadingMapEvent{EventBase: et, Map: mapName}, nil
+	case l.accept("Started map "):
+		mapName, ok := l.quoted()
+		if !ok || !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		return &StartedMapEvent{EventBase: et, Map: mapName}, nil
+	case l.accept("rcon from "):
+		return lexRcon(l, et)
+	case l.accept("server_cvar: "):
+		name, ok := l.quoted()
+		if !ok || !l.accept(" ") {
+			return nil, ErrUnhandledLine
+		}
+		value, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return &ServerCvarEvent{EventBase: et, Name: name, Value: value}, nil
+	case l.accept("server cvars "):
+		// The start and end markers of the cvar listing
+		return nil, ErrSkippedLine
+	case l.accept("Tournament mode started"):
+		return &TournamentStartedEvent{EventBase: et}, nil
+	case l.accept("Red Team: "):
+		return &TeamNameEvent{EventBase: et, Team: RED, Name: l.rest()}, nil
+	case l.accept("Blue Team: "):
+		return &TeamNameEvent{EventBase: et, Team: BLU, Name: l.rest()}, nil
+	}
+	start := l.pos
+	p1, ok := l.player()
+	if !ok {
+		l.pos = start
+		return lexCvarListing(l, et)
+	}
+	if !l.accept(" ") {
+		return nil, ErrUnhandledLine
+	}
+	switch {
+	case l.accept("triggered "):
+		name, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return lexTriggered(l, et, p1, name)
+	case l.accept("killed "):
+		p2, ok := l.player()
+		if !ok || !l.accept(" with ") {
+			return nil, ErrUnhandledLine
+		}
+		weapon, ok := l.quoted()
+		if !ok || !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		customKill, _ := l.props.get("customkill")
+		return &KillEvent{EventBase: et, Player: p1, Victim: p2, Weapon: weapon, CustomKill: customKill,
+			AttackerPos: l.props.pos("attacker_position"), VictimPos: l.props.pos("victim_position")}, nil
+	case l.accept("picked up item "):
+		item, ok := l.quoted()
+		if !ok || !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		ev := &PickupEvent{EventBase: et, Player: p1, Item: item}
+		if _, found := l.props.get("healing"); found {
+			var err error
+			if ev.Healing, err = l.props.int64("healing"); err != nil {
+				return nil, err
+			}
+		}
+		return ev, nil
+	case l.accept("spawned as "):
+		class, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return &SpawnedAsEvent{EventBase: et, Player: p1, Class: parsePlayerClass(class)}, nil
+	case l.accept("changed role to "):
+		class, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return &ChangeClassEvent{EventBase: et, Player: p1, Class: parsePlayerClass(class)}, nil
+	case l.accept("joined team "):
+		team, ok := l.quoted()
+		if !ok {
+			return nil, ErrUnhandledLine
+		}
+		return &JoinedTeamEvent{EventBase: et, Player: p1, NewTeam: parseTeam(team)}, nil
+	case l.accept("say_team "):
+		return lexSay(l, et, p1, true)
+	case l.accept("say "):
+		return lexSay(l, et, p1, false)
+	case l.accept("committed suicide with "):
+		weapon, ok := l.quoted()
+		if !ok || !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		return &SuicideEvent{EventBase: et, Player: p1, Weapon: weapon, AttackerPos: l.props.pos("attacker_position")}, nil
+	case l.accept("connected, address"):
+		l.skipSpace()
+		address, _ := l.quoted()
+		return &ConnectedEvent{EventBase: et, Player: p1, Address: address}, nil
+	case l.accept("disconnected"):
+		if !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		reason, _ := l.props.get("reason")
+		return &DisconnectedEvent{EventBase: et, Player: p1, Reason: reason}, nil
+	case l.accept("STEAM USERID validated"):
+		return &ValidatedEvent{EventBase: et, Player: p1}, nil
+	case l.accept("entered the game"):
+		return &EnteredEvent{EventBase: et, Player: p1}, nil
+	}
+	return nil, ErrUnhandledLine
+}
+
+func lexSay(l *lexer, et EventBase, p1 PlayerRef, teamChat bool) (Event, error) {
+	l.skipSpace()
+	msg := l.rest()
+	if len(msg) < 3 || msg[0] != '"' || msg[len(msg)-1] != '"' {
+		return nil, ErrUnhandledLine
+	}
+	return &SayEvent{EventBase: et, Player: p1, Message: msg[1 : len(msg)-1], TeamChat: teamChat}, nil
+}
+
+// lexCvarListing reads the `"name" = "value"` lines logged between "server cvars start" and
+// "server cvars end" when a map starts
+func lexCvarListing(l *lexer, et EventBase) (Event, error) {
+	name, ok := l.quoted()
+	if !ok || !l.accept(" = ") {
+		return nil, ErrUnhandledLine
+	}
+	value := l.rest()
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, ErrUnhandledLine
+	}
+	return &ServerCvarEvent{EventBase: et, Name: name, Value: value[1 : len(value)-1], Listing: true}, nil
+}
+
+// lexRcon reads the command sent over rcon. The command is everything up to the final quote
+// since commands such as say can include their own quotes.
+func lexRcon(l *lexer, et EventBase) (Event, error) {
+	address, ok := l.quoted()
+	if !ok || !l.accept(": command ") {
+		return nil, ErrUnhandledLine
+	}
+	cmd := l.rest()
+	if len(cmd) < 2 || cmd[0] != '"' || cmd[len(cmd)-1] != '"' {
+		return nil, ErrUnhandledLine
+	}
+	return &RconEvent{EventBase: et, Address: address, Command: cmd[1 : len(cmd)-1]}, nil
+}
+
+func lexTriggered(l *lexer, et EventBase, p1 PlayerRef, name string) (Event, error) {
+	var (
+		p2     PlayerRef
+		weapon string
+		ok     bool
+	)
+	if l.accept(" against ") {
+		if p2, ok = l.player(); !ok {
+			return nil, ErrUnhandledLine
+		}
+	}
+	if l.accept(" with ") {
+		if weapon, ok = l.quoted(); !ok {
+			return nil, ErrUnhandledLine
+		}
+	}
+	if !l.properties() {
+		return nil, ErrUnhandledLine
+	}
+	props := &l.props
+	switch name {
+	case "shot_fired":
+		weapon, _ = props.get("weapon")
+		return &ShotFiredEvent{EventBase: et, Player: p1, Weapon: weapon}, nil
+	case "shot_hit":
+		weapon, _ = props.get("weapon")
+		return &ShotHitEvent{EventBase: et, Player: p1, Weapon: weapon}, nil
+	case "damage":
+		ev := &DamageEvent{EventBase: et, Player: p1, Victim: p2}
+		var err error
+		if ev.Damage, err = props.int64("damage"); err != nil {
+			return nil, err
+		}
+		if _, found := props.get("realdamage"); found {
+			if ev.RealDamage, err = props.int64("realdamage"); err != nil {
+				return nil, err
+			}
+		}
+		if _, found := props.get("healing"); found {
+			if ev.Healing, err = props.int64("healing"); err != nil {
+				return nil, err
+			}
+		}
+		ev.Weapon, _ = props.get("weapon")
+		ev.Crit, _ = props.get("crit")
+		headshot, _ := props.get("headshot")
+		ev.Headshot = headshot == "1"
+		airshot, _ := props.get("airshot")
+		ev.Airshot = airshot == "1"
+		return ev, nil
+	case "kill assist":
+		return &KillAssistEvent{EventBase: et, Player: p1, Victim: p2, AssisterPos: props.pos("assister_position"),
+			AttackerPos: props.pos("attacker_position"), VictimPos: props.pos("victim_position")}, nil
+	case "domination":
+		return &DominationEvent{EventBase: et, Player: p1, Victim: p2}, nil
+	case "revenge":
+		assist, _ := props.get("assist")
+		return &RevengeEvent{EventBase: et, Player: p1, Victim: p2, Assist: assist == "1"}, nil
+	case "empty_uber":
+		return &EmptyUberEvent{EventBase: et, Player: p1}, nil
+	case "medic_death":
+		healing, err := props.int64("healing")
+		if err != nil {
+			return nil, err
+		}
+		uber, _ := props.get("ubercharge")
+		return &MedicDeathEvent{EventBase: et, Player: p1, Victim: p2, Healing: healing, HadUber: uber == "1"}, nil
+	case "medic_death_ex":
+		pct, err := props.int64("uberpct")
+		if err != nil {
+			return nil, err
+		}
+		return &MedicDeathExEvent{EventBase: et, Player: p1, UberPct: pct}, nil
+	case "lost_uber_advantage":
+		t, err := props.int64("time")
+		if err != nil {
+			return nil, err
+		}
+		return &LostUberAdvantageEvent{EventBase: et, Player: p1, Time: t}, nil
+	case "chargeready":
+		return &ChargeReadyEvent{EventBase: et, Player: p1}, nil
+	case "chargedeployed":
+		medigun, _ := props.get("medigun")
+		return &ChargeDeployedEvent{EventBase: et, Player: p1, Medigun: parseMedigun(medigun)}, nil
+	case "chargeended":
+		duration, err := props.float64("duration")
+		if err != nil {
+			return nil, err
+		}
+		return &ChargeEndedEvent{EventBase: et, Player: p1, Duration: duration}, nil
+	case "healed":
+		healing, err := props.int64("healing")
+		if err != nil {
+			return nil, err
+		}
+		return &HealedEvent{EventBase: et, Player: p1, Target: p2, Healing: healing}, nil
+	case "player_extinguished":
+		return &ExtinguishedEvent{EventBase: et, Player: p1, Target: p2, Weapon: weapon,
+			AttackerPos: props.pos("attacker_position"), VictimPos: props.pos("victim_position")}, nil
+	case "object_deflected":
+		ev := &DeflectedEvent{EventBase: et, Player: p1, Position: props.pos("object_position")}
+		ev.Weapon, _ = props.get("weapon")
+		owner, _ := props.get("owner")
+		var err error
+		if ev.Owner, err = ParsePlayerRef(owner); err != nil {
+			return nil, ErrUnhandledLine
+		}
+		return ev, nil
+	case "player_deflect":
+		weapon, _ = props.get("weapon")
+		return &PlayerDeflectEvent{EventBase: et, Player: p1, Victim: p2, Weapon: weapon,
+			AttackerPos: props.pos("attacker_position"), VictimPos: props.pos("victim_position")}, nil
+	case "player_builtobject":
+		object, _ := props.get("object")
+		return &BuiltObjectEvent{EventBase: et, Player: p1, Object: object, Position: props.pos("position")}, nil
+	case "player_carryobject":
+		object, _ := props.get("object")
+		return &CarryObjectEvent{EventBase: et, Player: p1, Object: object, Position: props.pos("position")}, nil
+	case "player_dropobject":
+		object, _ := props.get("object")
+		return &DropObjectEvent{EventBase: et, Player: p1, Object: object, Position: props.pos("position")}, nil
+	case "object_detonated":
+		object, _ := props.get("object")
+		return &DetonatedObjectEvent{EventBase: et, Player: p1, Object: object, Position: props.pos("position")}, nil
+	case "killedobject":
+		ev := &KilledObjectEvent{EventBase: et, Player: p1, AttackerPos: props.pos("attacker_position")}
+		ev.Object, _ = props.get("object")
+		ev.Weapon, _ = props.get("weapon")
+		owner, _ := props.get("objectowner")
+		var err error
+		if ev.Owner, err = ParsePlayerRef(owner); err != nil {
+			return nil, ErrUnhandledLine
+		}
+		if aspos, found := props.get("assister_position"); found {
+			ev.Assist = true
+			ev.AssisterPos = props.parsePos("assister_position", aspos)
+		}
+		return ev, nil
+	case "first_heal_after_spawn":
+		ht, err := props.float64("time")
+		if err != nil {
+			return nil, err
+		}
+		return &FirstHealAfterSpawnEvent{EventBase: et, Player: p1,
+			HealTime: time.Duration(ht * float64(time.Second))}, nil
+	case "flagevent":
+		action, _ := props.get("event")
+		return &FlagEvent{EventBase: et, Player: p1, Action: action, Position: props.pos("position")}, nil
+	case "captureblocked":
+		cp, err := props.int64("cp")
+		if err != nil {
+			return nil, err
+		}
+		cpName, _ := props.get("cpname")
+		return &CaptureBlockedEvent{EventBase: et, Player: p1, CP: int(cp), CPName: cpName,
+			Position: props.pos("position")}, nil
+	}
+	return nil, ErrUnhandledLine
+}
+
+func lexWorld(l *lexer, et EventBase) (Event, error) {
+	end := strings.IndexByte(l.rest(), '"')
+	if end < 0 {
+		return nil, ErrUnhandledLine
+	}
+	name := l.rest()[:end]
+	l.pos += end + 1
+	switch name {
+	case "Round_Start":
+		return &RoundStartEvent{EventBase: et}, nil
+	case "Round_Overtime":
+		return &RoundOvertimeEvent{EventBase: et}, nil
+	case "Game_Paused":
+		return &PausedEvent{EventBase: et}, nil
+	case "Game_Unpaused":
+		return &UnpausedEvent{EventBase: et}, nil
+	case "Game_Over":
+		if !l.accept(" reason ") {
+			return nil, ErrUnhandledLine
+		}
+		reason, _ := l.quoted()
+		return &GameOverEvent{EventBase: et, Reason: reason}, nil
+	case "Round_Win":
+		if !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		winner, _ := l.props.get("winner")
+		return &RoundWinEvent{EventBase: et, Winner: parseTeam(winner)}, nil
+	case "Round_Length":
+		if !l.properties() {
+			return nil, ErrUnhandledLine
+		}
+		seconds, _ := l.props.get("seconds")
+		dur, err := time.ParseDuration(seconds + "s")
+		if err != nil {
+			return nil, &FieldError{Field: "seconds", Value: seconds, Err: err}
+		}
+		return &RoundLengthEvent{EventBase: et, Length: dur}, nil
+	}
+	return nil, ErrUnhandledLine
+}
+
+func lexTeam(l *lexer, et EventBase) (Event, error) {
+	end := strings.IndexByte(l.rest(), '"')
+	if end < 0 {
+		return nil, ErrUnhandledLine
+	}
+	team := parseTeam(l.rest()[:end])
+	l.pos += end + 1
+	switch {
+	case l.accept(` triggered "pointcaptured"`):
+		return lexPointCaptured(l, et, team)
+	case l.accept(" current score "):
+		return lexTeamScore(l, et, team, false)
+	case l.accept(" final score "):
+		return lexTeamScore(l, et, team, true)
+	}
+	return nil, ErrUnhandledLine
+}
+
+func lexTeamScore(l *lexer, et EventBase, team Team, final bool) (Event, error) {
+	scoreStr, ok := l.quoted()
+	if !ok || !l.accept(" with ") {
+		return nil, ErrUnhandledLine
+	}
+	playersStr, ok := l.quoted()
+	if !ok {
+		return nil, ErrUnhandledLine
+	}
+	score, err := strconv.Atoi(scoreStr)
+	if err != nil {
+		return nil, &FieldError{Field: "score", Value: scoreStr, Err: err}
+	}
+	players, err := strconv.Atoi(playersStr)
+	if err != nil {
+		return nil, &FieldError{Field: "players", Value: playersStr, Err: err}
+	}
+	return &TeamScoreEvent{EventBase: et, Team: team, Score: score, Players: players, Final: final}, nil
+}
+
+func lexPointCaptured(l *lexer, et EventBase, team Team) (Event, error) {
+	if !l.properties() {
+		return nil, ErrUnhandledLine
+	}
+	cp, err := l.props.int64("cp")
+	if err != nil {
+		return nil, err
+	}
+	numCappers, err := l.props.int64("numcappers")
+	if err != nil {
+		return nil, err
+	}
+	ev := &PointCapturedEvent{EventBase: et, Team: team, CP: int(cp), NumCappers: int(numCappers)}
+	ev.CPName, _ = l.props.get("cpname")
+	for _, prop := range l.props.items {
+		if strings.HasPrefix(prop.Key, "player") {
+			p, err := ParsePlayerRef(prop.Value)
+			if err != nil {
+				return nil, ErrUnhandledLine
+			}
+			ev.Cappers = append(ev.Cappers, p)
+		} else if strings.HasPrefix(prop.Key, "position") {
+			ev.Positions = append(ev.Positions, l.props.parsePos(prop.Key, prop.Value))
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,163 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+	"time"
+)
+
+// testMatchLines is a short but complete match touching every message type we parse
+var testMatchLines = []string{
+	`L 07/10/2019 - 23:13:56: "Graba<3><[U:1:95947321]><>" STEAM USERID validated`,
+	`L 07/10/2019 - 23:15:20: "rad<6><[U:1:57823119]><>" connected, address "0.0.0.0:51378"`,
+	`L 07/10/2019 - 23:15:33: "rad<6><[U:1:57823119]><>" entered the game`,
+	`L 07/10/2019 - 23:16:05: "rad<6><[U:1:57823119]><Unassigned>" joined team "Red"`,
+	`L 07/10/2019 - 23:16:05: "wonder<7><[U:1:34284979]><Unassigned>" joined team "Red"`,
+	`L 07/10/2019 - 23:16:05: "z/<14><[U:1:66656848]><Unassigned>" joined team "Blue"`,
+	`L 07/10/2019 - 23:16:05: "Graba<3><[U:1:95947321]><Unassigned>" joined team "Blue"`,
+	`L 07/10/2019 - 23:16:05: "rad<6><[U:1:57823119]><Red>" changed role to "soldier"`,
+	`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+	`L 07/10/2019 - 23:28:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+	`L 07/10/2019 - 23:28:00: "wonder<7><[U:1:34284979]><Red>" spawned as "Medic"`,
+	`L 07/10/2019 - 23:28:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+	`L 07/10/2019 - 23:28:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+	`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`,
+	`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "shot_hit" (weapon "quake_rl")`,
+	`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl") (airshot "1")`,
+	`L 07/10/2019 - 23:28:02: "z/<14><[U:1:66656848]><Blue>" triggered "damage" against "rad<6><[U:1:57823119]><Red>" (damage "88") (realdamage "32") (weapon "scattergun") (healing "10")`,
+	`L 07/10/2019 - 23:28:02: "wonder<7><[U:1:34284979]><Red>" triggered "healed" against "rad<6><[U:1:57823119]><Red>" (healing "16")`,
+	`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" picked up item "medkit_medium"`,
+	`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" picked up item "ammopack_small"`,
+	`L 07/10/2019 - 23:28:03: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "-1688 -2242 795") (victim_position "-1666 -2536 690")`,
+	`L 07/10/2019 - 23:28:03: "wonder<7><[U:1:34284979]><Red>" triggered "kill assist" against "z/<14><[U:1:66656848]><Blue>" (assister_position "-1080 -1752 723") (attacker_position "-1688 -2242 795") (victim_position "-1666 -2536 690")`,
+	`L 07/10/2019 - 23:28:03: "rad<6><[U:1:57823119]><Red>" triggered "domination" against "z/<14><[U:1:66656848]><Blue>"`,
+	`L 07/10/2019 - 23:28:04: "z/<14><[U:1:66656848]><Blue>" triggered "revenge" against "rad<6><[U:1:57823119]><Red>" (assist "1")`,
+	`L 07/10/2019 - 23:28:05: "wonder<7><[U:1:34284979]><Red>" triggered "chargeready"`,
+	`L 07/10/2019 - 23:28:06: "wonder<7><[U:1:34284979]><Red>" triggered "chargedeployed" (medigun "medigun")`,
+	`L 07/10/2019 - 23:28:13: "wonder<7><[U:1:34284979]><Red>" triggered "chargeended" (duration "7.5")`,
+	`L 07/10/2019 - 23:28:13: "wonder<7><[U:1:34284979]><Red>" triggered "empty_uber"`,
+	`L 07/10/2019 - 23:28:14: "Graba<3><[U:1:95947321]><Blue>" triggered "lost_uber_advantage" (time "44")`,
+	`L 07/10/2019 - 23:28:15: "rad<6><[U:1:57823119]><Red>" triggered "medic_death" against "Graba<3><[U:1:95947321]><Blue>" (healing "3218") (ubercharge "1")`,
+	`L 07/10/2019 - 23:28:15: "Graba<3><[U:1:95947321]><Blue>" triggered "medic_death_ex" (uberpct "100")`,
+	`L 07/10/2019 - 23:28:15: "rad<6><[U:1:57823119]><Red>" killed "Graba<3><[U:1:95947321]><Blue>" with "quake_rl" (customkill "headshot") (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:28:16: "z/<14><[U:1:66656848]><Blue>" committed suicide with "world" (attacker_position "-1435 -1965 518")`,
+	`L 07/10/2019 - 23:28:17: "wonder<7><[U:1:34284979]><Red>" triggered "player_extinguished" against "rad<6><[U:1:57823119]><Red>" with "tf_weapon_medigun" (attacker_position "1907 2554 611") (victim_position "1728 2457 576")`,
+	`L 07/10/2019 - 23:28:18: "z/<14><[U:1:66656848]><Blue>" triggered "first_heal_after_spawn" (time "1.6")`,
+	`L 07/10/2019 - 23:28:19: "z/<14><[U:1:66656848]><Blue>" triggered "player_builtobject" (object "OBJ_SENTRYGUN") (position "-1689 -2062 59")`,
+	`L 07/10/2019 - 23:28:20: "z/<14><[U:1:66656848]><Blue>" triggered "player_carryobject" (object "OBJ_SENTRYGUN") (position "1822 -616 2")`,
+	`L 07/10/2019 - 23:28:21: "z/<14><[U:1:66656848]><Blue>" triggered "player_dropobject" (object "OBJ_SENTRYGUN") (position "1976 630 265")`,
+	`L 07/10/2019 - 23:28:22: "rad<6><[U:1:57823119]><Red>" triggered "killedobject" (object "OBJ_SENTRYGUN") (weapon "tf_projectile_rocket") (objectowner "z/<14><[U:1:66656848]><Blue>") (attacker_position "-359 -111 528")`,
+	`L 07/10/2019 - 23:28:23: "z/<14><[U:1:66656848]><Blue>" triggered "object_detonated" (object "OBJ_TELEPORTER") (position "470 1326 576")`,
+	`L 07/10/2019 - 23:28:24: "Graba<3><[U:1:95947321]><Blue>" triggered "captureblocked" (cp "2") (cpname "#Badlands_cap_cp3") (position "-266 343 0")`,
+	`L 07/10/2019 - 23:28:25: World triggered "Game_Paused"`,
+	`L 07/10/2019 - 23:29:25: World triggered "Game_Unpaused"`,
+	`L 07/10/2019 - 23:29:26: "rad<6><[U:1:57823119]><Red>" say "gg"`,
+	`L 07/10/2019 - 23:29:26: "wonder<7><[U:1:34284979]><Red>" say_team " 811 ms : Kwq"`,
+	`L 07/10/2019 - 23:29:30: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "2") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7") (player2 "wonder<7><[U:1:34284979]><Red>") (position2 "-105 118 5")`,
+	`L 07/10/2019 - 23:29:30: "rad<6><[U:1:57823119]><Red>" triggered "flagevent" (event "defended") (position "1 2 3")`,
+	`L 07/10/2019 - 23:29:31: World triggered "Round_Overtime"`,
+	`L 07/10/2019 - 23:30:00: World triggered "Round_Win" (winner "Red")`,
+	`L 07/10/2019 - 23:30:00: World triggered "Round_Length" (seconds "120.50")`,
+	`L 07/10/2019 - 23:30:00: Team "Red" current score "1" with "2" players`,
+	`L 07/10/2019 - 23:30:00: Team "Blue" current score "0" with "2" players`,
+	`L 07/10/2019 - 23:30:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+	`L 07/10/2019 - 23:30:10: Team "Red" final score "1" with "2" players`,
+	`L 07/10/2019 - 23:30:10: Team "Blue" final score "0" with "2" players`,
+	`L 07/10/2019 - 23:31:19: "z/<14><[U:1:66656848]><Blue>" disconnected (reason "z/ timed out")`,
+	`L 07/11/2019 - 00:50:12: "AMP_T<64><[U:1:163893616]><unknown>" spawned as "undefined"`,
+	`L 07/11/2019 - 00:50:12: rcon from "1.2.3.4:5": command "status"`,
+}
+
+func TestLexerEquivalence(t *testing.T) {
+	for _, line := range testMatchLines {
+		evRx, errRx := parseEventRx(line)
+		evLex, errLex := lexLine(line, ParseOptions{})
+		assert.Equal(t, errRx, errLex, line)
+		assert.Equal(t, evRx, evLex, line)
+	}
+}
+
+func TestLexerSummaryEquivalence(t *testing.T) {
+	sRx := NewSummary()
+	sLex := NewSummary()
+	for _, line := range testMatchLines {
+		if ev, err := parseEventRx(line); err == nil {
+			sRx.ApplyEvent(ev)
+		}
+		if ev, err := lexLine(line, ParseOptions{}); err == nil {
+			sLex.ApplyEvent(ev)
+		}
+	}
+	assertSummaryEqual(t, sRx, sLex)
+}
+
+// TestLexerBaselineEquivalence compares Apply against the regex parsers Apply was originally
+// built on using lines taken from real logs
+func TestLexerBaselineEquivalence(t *testing.T) {
+	// The samples are from different logs, order them so Apply does not correct the timestamps
+	type sample struct {
+		line string
+		ts   time.Time
+	}
+	var samples []sample
+	for _, line := range parseLineTests {
+		if ev, err := parseEventRx(line.Msg); err == nil {
+			samples = append(samples, sample{line.Msg, ev.Timestamp()})
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].ts.Before(samples[j].ts) })
+	var lines []string
+	for _, smp := range samples {
+		lines = append(lines, smp.line)
+	}
+	sRx := NewSummary()
+	sLex := NewSummary()
+	for _, line := range lines {
+		ev, err := parseEventRx(line)
+		require.NoError(t, err)
+		sRx.ApplyEvent(ev)
+		require.NoError(t, sLex.Apply(line))
+	}
+	require.NotEmpty(t, sLex.Players)
+	assertSummaryEqual(t, sRx, sLex)
+}
+
+func assertSummaryEqual(t *testing.T, expected *LogSummary, actual *LogSummary) {
+	require.Equal(t, len(expected.Players), len(actual.Players))
+	for sid, pExp := range expected.Players {
+		pAct, found := actual.Players[sid]
+		require.True(t, found)
+		// Avoid comparing the recursive summary reference
+		pExp.summary, pAct.summary = nil, nil
+		if pExp.HealingSum != nil {
+			assert.Equal(t, len(pExp.HealingSum.Targets), len(pAct.HealingSum.Targets))
+			pExp.HealingSum.Targets, pAct.HealingSum.Targets = nil, nil
+		}
+		assert.Equal(t, pExp, pAct)
+	}
+	assert.Equal(t, expected.Rounds, actual.Rounds)
+	assert.Equal(t, expected.Teams, actual.Teams)
+	assert.Equal(t, expected.ScoreRed, actual.ScoreRed)
+	assert.Equal(t, expected.TotalLength(), actual.TotalLength())
+	assert.Equal(t, len(expected.Messages), len(actual.Messages))
+}
+
+func BenchmarkParseEventRegex(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, line := range testMatchLines {
+			_, _ = parseEventRx(line)
+		}
+	}
+}
+
+func BenchmarkParseEventLexer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, line := range testMatchLines {
+			_, _ = lexLine(line, ParseOptions{})
+		}
+	}
+}
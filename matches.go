@@ -0,0 +1,101 @@
+package logstf
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// SplitMatches reads a raw server log, which may cover several maps and matches, and returns
+// a summary for each match played. A new match starts when a log file is started, a map is
+// loaded, a round starts after Game_Over or the tournament is restarted. Anything played
+// before mp_tournament_restart is discarded unless it reached Game_Over, as are segments
+// without a single finished round such as the time between map loads. The map of each match
+// is taken from the log itself.
+func SplitMatches(ctx context.Context, r io.Reader, opts ParseOptions) ([]*LogSummary, error) {
+	m := matchSplitter{opts: opts}
+	p := NewParserWithOptions(ctx, r, opts)
+	for p.Next() {
+		var parseErr error
+		if lineErr := p.LineErr(); lineErr != nil {
+			parseErr = lineErr.Err
+		}
+		ev := p.Event()
+		m.before(ev)
+		if err := m.current.applyParsed(p.Line(), p.Text(), ev, parseErr); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	m.finish()
+	return m.matches, nil
+}
+
+// matchSplitter holds the match currently being built by SplitMatches
+type matchSplitter struct {
+	opts    ParseOptions
+	matches []*LogSummary
+	current *LogSummary
+}
+
+// before starts a new match when the event marks a boundary, it is called before the event is
+// applied so the event is counted towards the new match
+func (m *matchSplitter) before(event Event) {
+	if m.current == nil {
+		m.start("", true)
+	}
+	switch ev := event.(type) {
+	case *LogStartedEvent:
+		m.start(m.current.Map, true)
+	case *LoadingMapEvent:
+		m.start(ev.Map, true)
+	case *RoundStartEvent:
+		if m.current.Phase() == PhaseGameOver {
+			m.start(m.current.Map, true)
+		}
+	case *RconEvent:
+		if isTournamentRestart(ev.Command) {
+			// Only unfinished play is thrown away, a match that reached Game_Over is kept
+			m.start(m.current.Map, m.current.Phase() == PhaseGameOver)
+		}
+	}
+}
+
+// start finishes the current match, keeping it when keep is set and anything was played, and
+// starts the next. The server details carry over to the next match.
+func (m *matchSplitter) start(mapName string, keep bool) {
+	prev := m.current
+	if keep {
+		m.finish()
+	}
+	s := NewSummary()
+	s.Options = m.opts
+	if prev != nil {
+		s.copyServerInfo(prev)
+	}
+	if mapName != "" {
+		s.setMap(mapName)
+	}
+	m.current = s
+}
+
+// finish keeps the current match if anything was played
+func (m *matchSplitter) finish() {
+	if m.current != nil && (m.current.Phase() == PhaseGameOver || len(m.current.Rounds) > 0) {
+		m.matches = append(m.matches, m.current)
+	}
+	m.current = nil
+}
+
+// isTournamentRestart checks each of the ; separated commands for mp_tournament_restart
+func isTournamentRestart(command string) bool {
+	for _, cmd := range strings.Split(command, ";") {
+		fields := strings.Fields(cmd)
+		if len(fields) > 0 && fields[0] == "mp_tournament_restart" {
+			return true
+		}
+	}
+	return false
+}
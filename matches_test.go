@@ -0,0 +1,88 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSplitMatches(t *testing.T) {
+	lines := []string{
+		`L 10/13/2019 - 20:00:00: Log file started (file "logs/L1013000.log") (game "/home/tf2/tf") (version "5409153")`,
+		`L 10/13/2019 - 20:00:00: Loading map "cp_process_final"`,
+		`L 10/13/2019 - 20:00:01: Started map "cp_process_final" (CRC "bb1e2a62d4e4cb8e2b4c63ec0d2b5ae7")`,
+		// Pub play before the match is set up
+		`L 10/13/2019 - 20:01:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:02:00: World triggered "Round_Win" (winner "Blue")`,
+		`L 10/13/2019 - 20:03:00: rcon from "1.2.3.4:27005": command "exec etf2l_6v6_5cp; mp_tournament_restart"`,
+		`L 10/13/2019 - 20:04:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:05:00: World triggered "Round_Win" (winner "Red")`,
+		`L 10/13/2019 - 20:05:00: World triggered "Round_Length" (seconds "60.00")`,
+		`L 10/13/2019 - 20:05:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+		`L 10/13/2019 - 20:05:10: Team "Red" final score "1" with "6" players`,
+		`L 10/13/2019 - 20:05:10: Team "Blue" final score "0" with "6" players`,
+		// Rematch on the same map without a restart
+		`L 10/13/2019 - 20:10:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:11:00: World triggered "Round_Win" (winner "Blue")`,
+		`L 10/13/2019 - 20:12:00: World triggered "Game_Over" reason "Reached Time Limit"`,
+		// Restarting after a finished match keeps it
+		`L 10/13/2019 - 20:13:00: rcon from "1.2.3.4:27005": command "mp_tournament_restart"`,
+		`L 10/13/2019 - 20:20:00: Loading map "koth_product_rcx"`,
+		`L 10/13/2019 - 20:20:01: Started map "koth_product_rcx" (CRC "14e0e7f0a7a2bbd26f1d4bb45a5e71b1")`,
+		`L 10/13/2019 - 20:21:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:22:00: World triggered "Round_Win" (winner "Red")`,
+		// Bad start, replayed from scratch
+		`L 10/13/2019 - 20:22:30: rcon from "1.2.3.4:27005": command "mp_tournament_restart"`,
+		`L 10/13/2019 - 20:23:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:24:00: World triggered "Round_Win" (winner "Blue")`,
+		`L 10/13/2019 - 20:25:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:26:00: World triggered "Round_Win" (winner "Blue")`,
+		`L 10/13/2019 - 20:26:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+		`L 10/13/2019 - 20:30:00: Loading map "cp_badlands"`,
+		`L 10/13/2019 - 20:30:01: Started map "cp_badlands" (CRC "14e0e7f0a7a2bbd26f1d4bb45a5e71b1")`,
+	}
+	matches, err := SplitMatches(context.Background(), strings.NewReader(strings.Join(lines, "\n")), ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(matches))
+
+	assert.Equal(t, "cp_process_final", matches[0].Map)
+	assert.Equal(t, Mode5CP, matches[0].GameMode)
+	assert.Equal(t, 1, len(matches[0].Rounds))
+	assert.Equal(t, 1, matches[0].ScoreRed)
+	assert.Equal(t, 0, matches[0].ScoreBlu)
+
+	assert.Equal(t, "cp_process_final", matches[1].Map)
+	assert.Equal(t, 1, matches[1].ScoreBlu)
+	assert.Equal(t, 0, matches[1].ScoreRed)
+
+	assert.Equal(t, "koth_product_rcx", matches[2].Map)
+	assert.Equal(t, ModeKOTH, matches[2].GameMode)
+	assert.Equal(t, 2, len(matches[2].Rounds))
+	assert.Equal(t, 0, matches[2].ScoreRed)
+	assert.Equal(t, 2, matches[2].ScoreBlu)
+}
+
+func TestServerEvents(t *testing.T) {
+	lines := []string{
+		`L 10/13/2019 - 20:00:00: Log file started (file "logs/L1013000.log") (game "/home/tf2/tf") (version "5409153")`,
+		`L 10/13/2019 - 20:00:00: Loading map "cp_process_final"`,
+		`L 10/13/2019 - 20:00:01: Started map "cp_process_final" (CRC "bb1e2a62d4e4cb8e2b4c63ec0d2b5ae7")`,
+		`L 10/13/2019 - 20:03:00: rcon from "1.2.3.4:27005": command "say "hello""`,
+	}
+	for _, line := range lines {
+		ev, err := ParseEvent(line)
+		require.NoError(t, err, line)
+		evRx, err := parseEventRx(line)
+		require.NoError(t, err, line)
+		assert.Equal(t, ev, evRx)
+		out, err := FormatEvent(ev)
+		require.NoError(t, err)
+		assert.Equal(t, line, out)
+	}
+	ev, _ := ParseEvent(lines[3])
+	assert.Equal(t, `say "hello"`, ev.(*RconEvent).Command)
+	assert.True(t, isTournamentRestart("exec x; mp_tournament_restart"))
+	assert.False(t, isTournamentRestart("mp_tournament_restart_later"))
+}
@@ -0,0 +1,64 @@
+package logstf
+
+import (
+	"time"
+)
+
+// deathAfterChargeWindow is how long after a charge runs out a medic death still counts
+// towards DeathsAfterCharge
+const deathAfterChargeWindow = 20 * time.Second
+
+func (s *LogSummary) medicSpawned(player *Player, ts time.Time) {
+	if player.HealingSum == nil {
+		return
+	}
+	player.HealingSum.buildStart = ts
+	player.HealingSum.chargeReadyAt = time.Time{}
+}
+
+func (s *LogSummary) chargeReady(player *Player, ts time.Time) {
+	player.HealingSum.chargeBuilt(ts)
+}
+
+func (s *LogSummary) medicDeath(player *Player, ts time.Time) {
+	h := player.HealingSum
+	if h == nil {
+		return
+	}
+	if !h.lastEmptyUber.IsZero() && ts.Sub(h.lastEmptyUber) <= deathAfterChargeWindow {
+		h.DeathsAfterCharge++
+	}
+	h.buildStart = time.Time{}
+	h.chargeReadyAt = time.Time{}
+}
+
+// chargeBuilt records the build time when the charge is ready, unknown when the medic was
+// already alive at the start of the log
+func (h *HealingSummary) chargeBuilt(ts time.Time) {
+	if !h.buildStart.IsZero() {
+		h.buildTimes = append(h.buildTimes, ts.Sub(h.buildStart))
+		h.AvgTimeToBuild = avgSeconds(h.buildTimes)
+	}
+	h.buildStart = time.Time{}
+	h.chargeReadyAt = ts
+}
+
+// chargeUsed records how long a ready charge was held before being deployed
+func (h *HealingSummary) chargeUsed(ts time.Time) {
+	if !h.chargeReadyAt.IsZero() {
+		h.useTimes = append(h.useTimes, ts.Sub(h.chargeReadyAt))
+		h.AvgTimeBeforeUsing = avgSeconds(h.useTimes)
+	}
+	h.chargeReadyAt = time.Time{}
+}
+
+func avgSeconds(durations []time.Duration) int {
+	if len(durations) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	return int((total / time.Duration(len(durations))).Round(time.Second).Seconds())
+}
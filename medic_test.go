@@ -0,0 +1,40 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMedicStats(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:00:40: "Graba<3><[U:1:95947321]><Blue>" triggered "chargeready"`,
+		`L 07/10/2019 - 23:00:45: "Graba<3><[U:1:95947321]><Blue>" triggered "chargedeployed" (medigun "medigun")`,
+		`L 07/10/2019 - 23:00:53: "Graba<3><[U:1:95947321]><Blue>" triggered "chargeended" (duration "8.0")`,
+		`L 07/10/2019 - 23:00:53: "Graba<3><[U:1:95947321]><Blue>" triggered "empty_uber"`,
+		`L 07/10/2019 - 23:00:53: "rad<6><[U:1:57823119]><Red>" triggered "lost_uber_advantage" (time "12")`,
+		`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" triggered "medic_death" against "Graba<3><[U:1:95947321]><Blue>" (healing "400") (ubercharge "0")`,
+		`L 07/10/2019 - 23:01:10: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:02:00: "Graba<3><[U:1:95947321]><Blue>" triggered "chargeready"`,
+		`L 07/10/2019 - 23:02:20: "Graba<3><[U:1:95947321]><Blue>" triggered "chargedeployed" (medigun "kritzkrieg")`,
+		`L 07/10/2019 - 23:02:26: "Graba<3><[U:1:95947321]><Blue>" triggered "chargeended" (duration "6.0")`,
+		`L 07/10/2019 - 23:02:26: "Graba<3><[U:1:95947321]><Blue>" triggered "empty_uber"`,
+		`L 07/10/2019 - 23:03:00: "Graba<3><[U:1:95947321]><Blue>" triggered "lost_uber_advantage" (time "44")`,
+		`L 07/10/2019 - 23:04:00: "rad<6><[U:1:57823119]><Red>" triggered "medic_death" against "Graba<3><[U:1:95947321]><Blue>" (healing "300") (ubercharge "1")`,
+	})
+	h := s.Players[76561198056213049].HealingSum
+	require.NotNil(t, h)
+	assert.Equal(t, 45, h.AvgTimeToBuild)
+	assert.Equal(t, 13, h.AvgTimeBeforeUsing)
+	assert.Equal(t, 1, h.DeathsAfterCharge)
+	assert.Equal(t, 1, h.Drops)
+	assert.Equal(t, 1, h.MajorAdvantagesLost)
+	assert.Equal(t, 44, h.BiggestAdvantageLost)
+	assert.Equal(t, 7.0, h.AvgUberLen())
+	table := h.Table("Graba")
+	for _, v := range []string{"45s", "13s", "7.0s", "44s"} {
+		assert.Contains(t, table, v)
+	}
+}
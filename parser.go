@@ -2,11 +2,9 @@ package logstf
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 )
 
 type MsgType int
@@ -40,6 +38,8 @@ const (
 	chargeEnded
 	healed
 	extinguished
+	objectDeflected
+	playerDeflect
 	builtObject
 	carryObject
 	killedObject
@@ -49,6 +49,7 @@ const (
 	captureBlocked
 	killedCustom
 	pointCaptured
+	flagEvent
 	wRoundOvertime
 	// World events not attached to specific players
 	wRoundStart
@@ -59,6 +60,14 @@ const (
 	wGameOver
 	wPaused
 	wUnpaused
+	// Server messages
+	logStarted
+	loadingMap
+	startedMap
+	rcon
+	serverCvar
+	tournamentStarted
+	tournamentTeamName
 	// Any messages we skip processing
 	skipped
 )
@@ -249,40 +258,57 @@ type Position struct {
 	Z int64
 }
 
-func parsePos(pos string) Position {
-	p := strings.SplitN(pos, " ", 3)
-	x, err := strconv.ParseInt(p[0], 10, 64)
-	if err != nil {
-		log.Warnf("Failed to parse x pos: %s", p[0])
-		x = 0
-	}
-	y, err := strconv.ParseInt(p[1], 10, 64)
-	if err != nil {
-		log.Warnf("Failed to parse y pos: %s", p[1])
-		y = 0
-	}
-	z, err := strconv.ParseInt(p[2], 10, 64)
-	if err != nil {
-		log.Warnf("Failed to parse z pos: %s", p[2])
-		z = 0
-	}
-	return Position{x, y, z}
+// FieldError is a value within a line that could not be converted into its type
+type FieldError struct {
+	Field string
+	Value string
+	Err   error
 }
 
-func parseDateTime(dateStr, timeStr string) time.Time {
-	fDateStr := fmt.Sprintf("%s %s", dateStr, timeStr)
-	t, err := time.Parse("02/01/2006 15:04:05", fDateStr)
-	if err != nil {
-		log.WithError(err).Errorf("Failed to parse date: %s", fDateStr)
-		return time.Now()
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("failed to parse %s %q: %v", e.Field, e.Value, e.Err)
+}
+
+// FieldErrors is returned alongside an event when optional fields such as positions failed to
+// parse. The event is still usable with the failed fields left as their zero value.
+type FieldErrors []*FieldError
+
+func (e FieldErrors) Error() string {
+	var msgs []string
+	for _, fe := range e {
+		msgs = append(msgs, fe.Error())
 	}
-	return t
+	return strings.Join(msgs, ", ")
+}
+
+// parsePos converts a "x y z" position, any invalid axis is left as 0
+func parsePos(pos string) Position {
+	p, _ := parsePosition(pos)
+	return p
 }
 
-func parseParams(body string) []string {
-	b := strings.ReplaceAll(strings.ReplaceAll(body, "(", ""), "\"", "")
-	pcs := strings.Split(strings.ReplaceAll(b, ")", ""), " ")
-	return pcs
+func parsePosition(pos string) (Position, error) {
+	var (
+		p      [3]int64
+		posErr error
+	)
+	for i := range p {
+		v := pos
+		if i < 2 {
+			if idx := strings.IndexByte(pos, ' '); idx >= 0 {
+				v, pos = pos[:idx], pos[idx+1:]
+			} else {
+				pos = ""
+			}
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			posErr = err
+			n = 0
+		}
+		p[i] = n
+	}
+	return Position{p[0], p[1], p[2]}, posErr
 }
 
 func isRealDamageWeapon(weapon string) bool {
@@ -305,11 +331,11 @@ func init() {
 	dp := rxDate + rxPlayerStr + `\s+`
 
 	rxSkipped := regexp.MustCompile(`("undefined"$)`)
-	rxConnected := regexp.MustCompile(dp + `connected, address`)
+	rxConnected := regexp.MustCompile(dp + `connected, address(?: "(?P<address>[^"]*)")?`)
 	rxDisconnected := regexp.MustCompile(dp + `disconnected \(reason "(?P<reason>.+?)"\)`)
 	rxValidated := regexp.MustCompile(dp + `STEAM USERID validated$`)
 	rxEntered := regexp.MustCompile(dp + `entered the game`)
-	rxJoinedTeam := regexp.MustCompile(dp + `joined team "(?P<team>(Red|Blue|Spectator))"`)
+	rxJoinedTeam := regexp.MustCompile(dp + `joined team "(?P<newteam>(Red|Blue|Spectator))"`)
 	rxChangeClass := regexp.MustCompile(dp + `changed role to "(?P<class>.+?)"`)
 	rxSpawned := regexp.MustCompile(dp + `spawned as "(?P<class>\S+)"`)
 	rxSuicide := regexp.MustCompile(dp + `committed suicide with "world" \(attacker_position "(?P<pos>.+?)"\)`)
@@ -331,12 +357,14 @@ func init() {
 	rxEmptyUber := regexp.MustCompile(dp + `triggered "empty_uber"`)
 	rxMedicDeath := regexp.MustCompile(dp + `triggered "medic_death" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue)?)>" \(healing "(?P<healing>\d+)"\) \(ubercharge "(?P<uber>\d+)"\)`)
 	rxMedicDeathEx := regexp.MustCompile(dp + `triggered "medic_death_ex" \(uberpct "(?P<pct>\d+)"\)`)
-	rxLostUberAdv := regexp.MustCompile(dp + `triggered "lost_uber_advantage" \(time "(?P<time>\d+)"\)`)
+	rxLostUberAdv := regexp.MustCompile(dp + `triggered "lost_uber_advantage" \(time "(?P<advtime>\d+)"\)`)
 	rxChargeReady := regexp.MustCompile(dp + `triggered "chargeready"`)
 	rxChargeDeployed := regexp.MustCompile(dp + `triggered "chargedeployed"( \(medigun "(?P<medigun>.+?)"\))?`)
 	rxChargeEnded := regexp.MustCompile(dp + `triggered "chargeended" \(duration "(?P<duration>.+?)"\)`)
 	rxHealed := regexp.MustCompile(dp + `triggered "healed" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>" \(healing "(?P<healing>\d+)"\)`)
 	rxExtinguished := regexp.MustCompile(dp + `triggered "player_extinguished" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Red|Blue)?)>" with "(?P<weapon>.+?)" \(attacker_position "(?P<apos>.+?)"\) \(victim_position "(?P<vpos>.+?)"\)`)
+	rxObjectDeflected := regexp.MustCompile(dp + `triggered "object_deflected" \(weapon "(?P<weapon>.+?)"\) \(owner "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>"\)( \(object_position "(?P<pos>.+?)"\))?`)
+	rxPlayerDeflect := regexp.MustCompile(dp + `triggered "player_deflect" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>"( \(weapon "(?P<weapon>.+?)"\))?( \(attacker_position "(?P<apos>.+?)"\))?( \(victim_position "(?P<vpos>.+?)"\))?`)
 	rxBuiltObject := regexp.MustCompile(dp + `triggered "player_builtobject" \(object "(?P<object>.+?)"\) \(position "(?P<Position>.+?)"\)`)
 	rxCarryObject := regexp.MustCompile(dp + `triggered "player_carryobject" \(object "(?P<object>.+?)"\) \(position "(?P<Position>.+?)"\)`)
 	rxDropObject := regexp.MustCompile(dp + `triggered "player_dropobject" \(object "(?P<object>.+?)"\) \(position "(?P<Position>.+?)"\)`)
@@ -352,9 +380,19 @@ func init() {
 	rxWTeamFinalScore := regexp.MustCompile(rxDate + `Team "(?P<team>Red|Blue)" final score "(?P<score>\d+)" with "(?P<players>\d+)" players`)
 	rxWTeamScore := regexp.MustCompile(rxDate + `Team "(?P<team>Red|Blue)" current score "(?P<score>\d+)" with "(?P<players>\d+)" players`)
 	rxCaptureBlocked := regexp.MustCompile(dp + `triggered "captureblocked" \(cp "(?P<cp>\d+)"\) \(cpname "(?P<cpname>.+?)"\) \(position "(?P<pos>.+?)"\)`)
+	rxFlagEvent := regexp.MustCompile(dp + `triggered "flagevent" \(event "(?P<event>.+?)"\)`)
 	rxPointCaptured := regexp.MustCompile(rxDate + `Team "(?P<team>.+?)" triggered "pointcaptured" \(cp "(?P<cp>\d+)"\) \(cpname "(?P<cpname>.+?)"\) \(numcappers "(?P<numcappers>\d+)"\)(\s+(?P<body>.+?))$`)
 	rxWPaused := regexp.MustCompile(rxDate + `World triggered "Game_Paused"`)
 	rxWUnpaused := regexp.MustCompile(rxDate + `World triggered "Game_Unpaused"`)
+	rxLogStarted := regexp.MustCompile(rxDate + `Log file started \(file "(?P<file>.*?)"\) \(game "(?P<game>.*?)"\) \(version "(?P<version>.*?)"\)`)
+	rxLoadingMap := regexp.MustCompile(rxDate + `Loading map "(?P<map>.+?)"`)
+	rxStartedMap := regexp.MustCompile(rxDate + `Started map "(?P<map>.+?)"`)
+	rxServerCvar := regexp.MustCompile(rxDate + `server_cvar: "(?P<cvar>[^"]+)" "(?P<value>.*)"$`)
+	rxCvarListing := regexp.MustCompile(rxDate + `"(?P<cvar>[^"]+)" = "(?P<listvalue>.*)"$`)
+	rxCvarMarker := regexp.MustCompile(rxDate + `server cvars (start|end)`)
+	rxTournamentStarted := regexp.MustCompile(rxDate + `Tournament mode started`)
+	rxTeamName := regexp.MustCompile(rxDate + `(?P<team>Red|Blue) Team: (?P<teamname>.*)$`)
+	rxRcon := regexp.MustCompile(rxDate + `rcon from "(?P<address>.*?)": command "(?P<command>.*)"$`)
 	// Associate matching rx's with a MsgType
 	// Should be ordered by most common events first to reduce running all the rx's as much as possible
 	rxParsers = []parserType{
@@ -387,6 +425,8 @@ func init() {
 		{rxMedicDeath, medicDeath},
 		{rxMedicDeathEx, medicDeathEx},
 		{rxExtinguished, extinguished},
+		{rxObjectDeflected, objectDeflected},
+		{rxPlayerDeflect, playerDeflect},
 		{rxBuiltObject, builtObject},
 		{rxCarryObject, carryObject},
 		{rxDropObject, dropObject},
@@ -395,6 +435,7 @@ func init() {
 		{rxDetonatedObject, detonatedObject},
 		{rxFirstHealAfterSpawn, firstHealAfterSpawn},
 		{rxPointCaptured, pointCaptured},
+		{rxFlagEvent, flagEvent},
 		{rxCaptureBlocked, captureBlocked},
 		{rxDisconnected, disconnected},
 		{rxWOvertime, wRoundOvertime},
@@ -406,6 +447,15 @@ func init() {
 		{rxWTeamFinalScore, wTeamFinalScore},
 		{rxWPaused, wPaused},
 		{rxWUnpaused, wUnpaused},
+		{rxLogStarted, logStarted},
+		{rxLoadingMap, loadingMap},
+		{rxStartedMap, startedMap},
+		{rxRcon, rcon},
+		{rxServerCvar, serverCvar},
+		{rxCvarListing, serverCvar},
+		{rxCvarMarker, skipped},
+		{rxTournamentStarted, tournamentStarted},
+		{rxTeamName, tournamentTeamName},
 		{rxSkipped, skipped},
 	}
 }
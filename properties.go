@@ -0,0 +1,128 @@
+package logstf
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidProperties is returned when a property suffix does not follow the `(key "value")` grammar
+var ErrInvalidProperties = errors.New("invalid properties")
+
+// Property is a single `(key "value")` pair from the end of a log line
+type Property struct {
+	Key   string
+	Value string
+}
+
+// Properties is the ordered list of properties appended to a line. Plugins such as SupStats2
+// append their own properties to the stock lines, so anything not mapped to an event field is
+// still available here.
+type Properties []Property
+
+// Get returns the value of the first property with the key
+func (p Properties) Get(key string) (string, bool) {
+	for _, prop := range p {
+		if prop.Key == key {
+			return prop.Value, true
+		}
+	}
+	return "", false
+}
+
+// Has returns true when the key is present regardless of its value
+func (p Properties) Has(key string) bool {
+	_, found := p.Get(key)
+	return found
+}
+
+// Int64 returns the value of key as an integer
+func (p Properties) Int64(key string) (int64, error) {
+	v, found := p.Get(key)
+	if !found {
+		return 0, &FieldError{Field: key, Value: v, Err: errors.New("missing")}
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, &FieldError{Field: key, Value: v, Err: err}
+	}
+	return i, nil
+}
+
+// ParseProperties parses a sequence of `(key "value")` pairs separated by whitespace. Values
+// may contain spaces, parentheses and quotes as long as they are not directly followed by the
+// closing `")` of the pair.
+func ParseProperties(s string) (Properties, error) {
+	var props Properties
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return props, nil
+		}
+		key, value, rest, ok := scanProperty(s)
+		if !ok {
+			return props, ErrInvalidProperties
+		}
+		props = append(props, Property{Key: key, Value: value})
+		s = rest
+	}
+}
+
+// scanProperty reads a single property from the start of s, returning the remainder of s.
+// A key without any value such as `(airshot)` returns an empty value.
+func scanProperty(s string) (key string, value string, rest string, ok bool) {
+	if len(s) < 3 || s[0] != '(' {
+		return "", "", s, false
+	}
+	s = s[1:]
+	end := strings.IndexAny(s, " )")
+	if end <= 0 {
+		return "", "", s, false
+	}
+	key = s[:end]
+	if s[end] == ')' {
+		return key, "", s[end+1:], true
+	}
+	s = s[end+1:]
+	if len(s) == 0 || s[0] != '"' {
+		return "", "", s, false
+	}
+	s = s[1:]
+	offset := 0
+	for {
+		i := strings.Index(s[offset:], `")`)
+		if i < 0 {
+			return "", "", s, false
+		}
+		i += offset
+		next := i + 2
+		if next == len(s) || s[next] == ' ' || s[next] == '(' {
+			return key, s[:i], s[next:], true
+		}
+		offset = i + 1
+	}
+}
+
+// findProperties returns the properties at the end of a line. The properties start at the
+// first ` (` that allows the rest of the line to parse.
+func findProperties(line string) Properties {
+	_, props := findPropertiesIndex(line)
+	return props
+}
+
+// findPropertiesIndex is findProperties also returning the index the properties start at, -1
+// when there are none
+func findPropertiesIndex(line string) (int, Properties) {
+	offset := 0
+	for {
+		i := strings.Index(line[offset:], " (")
+		if i < 0 {
+			return -1, nil
+		}
+		i += offset
+		if props, err := ParseProperties(line[i+1:]); err == nil {
+			return i + 1, props
+		}
+		offset = i + 1
+	}
+}
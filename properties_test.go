@@ -0,0 +1,51 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	props, err := ParseProperties(`(damage "88") (realdamage "32")  (weapon "ubersaw") (crit "mini") (airshot)`)
+	require.NoError(t, err)
+	assert.Equal(t, Properties{
+		{"damage", "88"}, {"realdamage", "32"}, {"weapon", "ubersaw"}, {"crit", "mini"}, {"airshot", ""},
+	}, props)
+	v, found := props.Get("weapon")
+	assert.True(t, found)
+	assert.Equal(t, "ubersaw", v)
+	assert.True(t, props.Has("airshot"))
+	assert.False(t, props.Has("healing"))
+
+	// Values containing spaces, quotes and parentheses
+	props, err = ParseProperties(`(reason "Kicked by Console : "bye" (now)") (player1 "a (b) c<1><[U:1:1]><Red>")`)
+	require.NoError(t, err)
+	assert.Equal(t, Properties{
+		{"reason", `Kicked by Console : "bye" (now)`}, {"player1", "a (b) c<1><[U:1:1]><Red>"},
+	}, props)
+
+	_, err = ParseProperties(`(damage "88`)
+	assert.Equal(t, ErrInvalidProperties, err)
+	_, err = ParseProperties(`damage "88"`)
+	assert.Equal(t, ErrInvalidProperties, err)
+}
+
+func TestEventProperties(t *testing.T) {
+	line := `L 07/10/2019 - 23:29:54: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "62") (weapon "tf_projectile_rocket") (crit "crit") (headshot "1") (height "142")`
+	for _, parse := range []func(string) (Event, error){ParseEvent, parseEventRx} {
+		ev, err := parse(line)
+		require.NoError(t, err)
+		dmg := ev.(*DamageEvent)
+		assert.Equal(t, int64(62), dmg.Damage)
+		assert.Equal(t, "crit", dmg.Crit)
+		assert.True(t, dmg.Headshot)
+		height, found := dmg.Properties.Get("height")
+		assert.True(t, found)
+		assert.Equal(t, "142", height)
+	}
+	ev, err := ParseEvent(`L 07/10/2019 - 23:29:54: "rad<6><[U:1:57823119]><Red>" picked up item "medkit_small" (healing "20")`)
+	require.NoError(t, err)
+	healing, _ := ev.(*PickupEvent).Properties.Get("healing")
+	assert.Equal(t, "20", healing)
+}
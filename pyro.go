@@ -0,0 +1,41 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"strings"
+	"time"
+)
+
+// Extinguish is a burning teammate put out by the player
+type Extinguish struct {
+	Target      steamid.SID64
+	Weapon      string
+	AttackerPos Position
+	VictimPos   Position
+	CreatedOn   time.Time
+}
+
+// isReflectWeapon checks for kills with reflected projectiles which are logged as deflect_*
+// eg. deflect_rocket or deflect_promode
+func isReflectWeapon(weapon string) bool {
+	return strings.HasPrefix(weapon, "deflect_")
+}
+
+func (s *LogSummary) extinguished(player *Player, target *Player, weapon string, apos Position, vpos Position, dt time.Time) {
+	player.Extinguishes = append(player.Extinguishes, Extinguish{
+		Target:      target.SteamId,
+		Weapon:      weapon,
+		AttackerPos: apos,
+		VictimPos:   vpos,
+		CreatedOn:   dt,
+	})
+	target.Extinguished++
+}
+
+func (s *LogSummary) objectDeflected(player *Player, projectile string) {
+	player.Deflects[projectile]++
+}
+
+func (s *LogSummary) playerDeflected(player *Player) {
+	player.PlayerDeflects++
+}
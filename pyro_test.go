@@ -0,0 +1,43 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestPyroStats(t *testing.T) {
+	deflects := []string{
+		`L 07/10/2019 - 23:00:10: "z/<14><[U:1:66656848]><Blue>" triggered "object_deflected" (weapon "tf_projectile_rocket") (owner "rad<6><[U:1:57823119]><Red>") (object_position "1 2 3")`,
+		`L 07/10/2019 - 23:00:11: "z/<14><[U:1:66656848]><Blue>" triggered "object_deflected" (weapon "tf_projectile_pipe") (owner "wonder<7><[U:1:34284979]><Red>") (object_position "1 2 3")`,
+		`L 07/10/2019 - 23:00:12: "z/<14><[U:1:66656848]><Blue>" triggered "player_deflect" against "rad<6><[U:1:57823119]><Red>" (weapon "flamethrower") (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	}
+	for _, line := range deflects {
+		ev, err := ParseEvent(line)
+		require.NoError(t, err, line)
+		evRx, err := parseEventRx(line)
+		require.NoError(t, err, line)
+		assert.Equal(t, ev, evRx)
+		out, err := FormatEvent(ev)
+		require.NoError(t, err)
+		assert.Equal(t, line, out)
+	}
+	lines := append([]string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Pyro"`,
+		`L 07/10/2019 - 23:00:05: "z/<14><[U:1:66656848]><Blue>" triggered "player_extinguished" against "Graba<3><[U:1:95947321]><Blue>" with "tf_weapon_flamethrower" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	}, deflects...)
+	lines = append(lines,
+		`L 07/10/2019 - 23:00:10: "z/<14><[U:1:66656848]><Blue>" killed "rad<6><[U:1:57823119]><Red>" with "deflect_rocket" (attacker_position "1 2 3") (victim_position "4 5 6")`)
+	s := applyLines(t, lines)
+	pyro := s.Players[76561198026922576]
+	require.Equal(t, 1, len(pyro.Extinguishes))
+	assert.Equal(t, Extinguish{Target: 76561198056213049, Weapon: "tf_weapon_flamethrower",
+		AttackerPos: Position{1, 2, 3}, VictimPos: Position{4, 5, 6},
+		CreatedOn: time.Date(2019, 7, 10, 23, 0, 5, 0, time.UTC)}, pyro.Extinguishes[0])
+	assert.Equal(t, 1, s.Players[76561198056213049].Extinguished)
+	assert.Equal(t, map[string]int{"tf_projectile_rocket": 1, "tf_projectile_pipe": 1}, pyro.Deflects)
+	assert.Equal(t, 1, pyro.PlayerDeflects)
+	assert.Equal(t, map[string]int{"deflect_rocket": 1}, pyro.ReflectKills)
+}
@@ -0,0 +1,108 @@
+package logstf
+
+import (
+	"strings"
+)
+
+// maxReportSamples is the number of example lines kept for each unhandled line shape
+const maxReportSamples = 3
+
+// LineSample is an example of an unhandled line
+type LineSample struct {
+	Line int
+	Text string
+}
+
+// LineFieldError is a field conversion failure along with the line it occurred on
+type LineFieldError struct {
+	Line int
+	Text string
+	FieldError
+}
+
+// ParseReport collects everything the parser did not understand while building a LogSummary.
+// Unhandled lines are grouped by their shape, which is the line with the timestamp removed and
+// all quoted values replaced by * except for the name following "triggered".
+type ParseReport struct {
+	Lines       int
+	Skipped     int
+	Unhandled   map[string]int
+	Samples     map[string][]LineSample
+	FieldErrors []LineFieldError
+	EventErrors []LineError // Lines that parsed but do not agree with the rest of the log
+}
+
+func NewParseReport() *ParseReport {
+	return &ParseReport{Unhandled: map[string]int{}, Samples: map[string][]LineSample{}}
+}
+
+// UnhandledCount returns the total number of unhandled lines across all shapes
+func (r *ParseReport) UnhandledCount() int {
+	total := 0
+	for _, c := range r.Unhandled {
+		total += c
+	}
+	return total
+}
+
+// record stores the error returned from ParseEvent for the line
+func (r *ParseReport) record(lineNum int, text string, err error) {
+	switch e := err.(type) {
+	case *FieldError:
+		r.FieldErrors = append(r.FieldErrors, LineFieldError{Line: lineNum, Text: text, FieldError: *e})
+	case FieldErrors:
+		for _, fe := range e {
+			r.FieldErrors = append(r.FieldErrors, LineFieldError{Line: lineNum, Text: text, FieldError: *fe})
+		}
+	default:
+		if err == ErrSkippedLine {
+			r.Skipped++
+			return
+		}
+		shape := lineShape(text)
+		r.Unhandled[shape]++
+		if len(r.Samples[shape]) < maxReportSamples {
+			r.Samples[shape] = append(r.Samples[shape], LineSample{Line: lineNum, Text: text})
+		}
+	}
+}
+
+// recordEvent stores an error found while applying the event of the line
+func (r *ParseReport) recordEvent(lineNum int, text string, err error) {
+	r.EventErrors = append(r.EventErrors, LineError{Line: lineNum, Text: text, Err: err})
+}
+
+// lineShape reduces a line down to the parts that identify the kind of message
+func lineShape(text string) string {
+	if strings.HasPrefix(text, "L ") {
+		if i := strings.Index(text, " - "); i >= 0 {
+			if j := strings.Index(text[i:], ": "); j >= 0 {
+				text = text[i+j+2:]
+			}
+		}
+	}
+	var b strings.Builder
+	for len(text) > 0 {
+		q := strings.IndexByte(text, '"')
+		if q < 0 {
+			b.WriteString(text)
+			break
+		}
+		b.WriteString(text[:q+1])
+		keep := strings.HasSuffix(text[:q], "triggered ")
+		rest := text[q+1:]
+		end := strings.IndexByte(rest, '"')
+		if end < 0 {
+			b.WriteString(rest)
+			break
+		}
+		if keep {
+			b.WriteString(rest[:end])
+		} else {
+			b.WriteString("*")
+		}
+		b.WriteByte('"')
+		text = rest[end+1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package logstf
+
+import (
+	"context"
+	"errors"
+	"github.com/leighmacdonald/steamid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestLineShape(t *testing.T) {
+	assert.Equal(t, `"*" triggered "object_deflected" (weapon "*") (owner "*")`,
+		lineShape(`L 10/25/2019 - 12:14:45: "von<16><[U:1:181030438]><Blue>" triggered "object_deflected" (weapon "deflect_rocket") (owner "x<1><[U:1:1]><Red>")`))
+	assert.Equal(t, `server_cvar: "*" "*"`, lineShape(`L 10/25/2019 - 12:14:45: server_cvar: "mp_timelimit" "30"`))
+}
+
+func TestParseReport(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:28:01: server_message: "quit"`,
+		`L 07/10/2019 - 23:28:01: server_message: "restart"`,
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "lots") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:28:03: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 x") (victim_position "4 5 6")`,
+		`L 07/11/2019 - 00:50:12: "AMP_T<64><[U:1:163893616]><unknown>" spawned as "undefined"`,
+	}
+	s := NewSummary()
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines, "\n"))))
+	r := s.Report
+	assert.Equal(t, 6, r.Lines)
+	assert.Equal(t, 1, r.Skipped)
+	assert.Equal(t, 2, r.UnhandledCount())
+	assert.Equal(t, 2, r.Unhandled[`server_message: "*"`])
+	assert.Equal(t, []LineSample{{2, lines[1]}, {3, lines[2]}}, r.Samples[`server_message: "*"`])
+	require.Len(t, r.FieldErrors, 2)
+	assert.Equal(t, 4, r.FieldErrors[0].Line)
+	assert.Equal(t, "damage", r.FieldErrors[0].Field)
+	assert.Equal(t, "lots", r.FieldErrors[0].Value)
+	assert.Equal(t, 5, r.FieldErrors[1].Line)
+	assert.Equal(t, "attacker_position", r.FieldErrors[1].Field)
+	// The kill is still counted with the bad axis zeroed
+	rad := s.Players[steamid.SID64(76561198018088847)]
+	require.NotNil(t, rad)
+	require.Len(t, rad.Kills, 1)
+	assert.Equal(t, Position{1, 2, 0}, rad.Kills[0].APOS)
+}
+
+func TestParseReportStrict(t *testing.T) {
+	s := NewSummary()
+	s.Strict = true
+	assert.NoError(t, s.Apply(`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`))
+	err := s.Apply(`L 07/10/2019 - 23:28:01: server_message: "quit"`)
+	require.Error(t, err)
+	lineErr, ok := err.(*LineError)
+	require.True(t, ok)
+	assert.Equal(t, 2, lineErr.Line)
+	assert.Equal(t, ErrUnhandledLine, lineErr.Err)
+
+	err = s.ApplyReader(context.Background(), strings.NewReader(
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`+"\n"+
+			`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "x")`))
+	require.Error(t, err)
+	// The line count carries on from the lines given to Apply
+	assert.Equal(t, `line 4: failed to parse healing "x": strconv.ParseInt: parsing "x": invalid syntax`, err.Error())
+
+	// Lines that parse but do not agree with the rest of the log are only reported
+	assert.NoError(t, s.Apply(`L 07/10/2019 - 23:28:03: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "2") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`))
+	require.Len(t, s.Report.EventErrors, 1)
+	assert.Equal(t, 5, s.Report.EventErrors[0].Line)
+	assert.True(t, errors.Is(s.Report.EventErrors[0].Err, ErrCapperCount))
+}
+
+func TestParseReportEventErrors(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:28:03: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "2") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:29:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:30:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+		`L 07/10/2019 - 23:30:10: Team "Red" final score "2" with "6" players`,
+	}
+	s := NewSummary()
+	require.NoError(t, s.Apply(lines[0]))
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines[1:], "\n"))))
+	require.Len(t, s.Report.EventErrors, 2)
+	assert.Equal(t, 2, s.Report.EventErrors[0].Line)
+	assert.Equal(t, lines[1], s.Report.EventErrors[0].Text)
+	assert.True(t, errors.Is(s.Report.EventErrors[0].Err, ErrCapperCount))
+	assert.Equal(t, 5, s.Report.EventErrors[1].Line)
+	assert.True(t, errors.Is(s.Report.EventErrors[1].Err, ErrScoreMismatch))
+}
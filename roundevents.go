@@ -0,0 +1,66 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"time"
+)
+
+// RoundEventType is the kind of entry in a round or match timeline, the names match the event
+// types used by logs.tf
+type RoundEventType string
+
+const (
+	RoundEventCharge     RoundEventType = "charge"
+	RoundEventPointCap   RoundEventType = "pointcap"
+	RoundEventMedicDeath RoundEventType = "medic_death"
+	RoundEventDrop       RoundEventType = "drop"
+	RoundEventFirstBlood RoundEventType = "first_blood"
+	RoundEventPause      RoundEventType = "pause"
+	RoundEventUnpause    RoundEventType = "unpause"
+)
+
+// RoundEvent is a single entry in the timeline. Only the fields relevant to the type are set.
+type RoundEvent struct {
+	Type      RoundEventType
+	Time      time.Duration // Game time since the start of the round, zero outside of a round
+	MatchTime time.Duration // Game time since the start of the first round, see GameTime
+	CreatedOn time.Time
+	Round     int // Zero outside of a round
+	Phase     MatchPhase
+	Team      Team
+	SteamId   steamid.SID64 // The medic for charges, drops and medic deaths, the killer for first blood
+	Killer    steamid.SID64 // Who killed the medic
+	Victim    steamid.SID64 // Who died for first blood
+	Point     int
+	Medigun   Medigun
+}
+
+// Timeline returns every timeline event of the match in order, including those that happened
+// between rounds
+func (s *LogSummary) Timeline() []RoundEvent {
+	return s.events
+}
+
+// addRoundEvent fills in the times and adds the event to the match timeline and the current
+// round when one is being played
+func (s *LogSummary) addRoundEvent(ev RoundEvent) {
+	ev.CreatedOn = s.now
+	ev.MatchTime = s.GameTime(s.now)
+	ev.Phase = s.phase
+	if s.roundStarted && s.currentRoundSummary != nil {
+		ev.Round = s.currentRound
+		ev.Time = s.gameDuration(s.roundStartTime, s.now)
+		s.currentRoundSummary.Events = append(s.currentRoundSummary.Events, ev)
+	}
+	s.events = append(s.events, ev)
+}
+
+// firstBlood adds the first kill of the round to the timeline
+func (s *LogSummary) firstBlood(killer *Player, victim *Player) {
+	if !s.roundStarted || s.currentRoundSummary == nil || s.currentRoundSummary.firstBlood {
+		return
+	}
+	s.currentRoundSummary.firstBlood = true
+	s.addRoundEvent(RoundEvent{Type: RoundEventFirstBlood, Team: killer.Team, SteamId: killer.SteamId,
+		Victim: victim.SteamId})
+}
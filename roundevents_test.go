@@ -0,0 +1,60 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRoundTimeline(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+		`L 07/10/2019 - 23:00:10: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:00:15: "z/<14><[U:1:66656848]><Blue>" killed "rad<6><[U:1:57823119]><Red>" with "scattergun" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:00:30: "Graba<3><[U:1:95947321]><Blue>" triggered "chargedeployed" (medigun "kritzkrieg")`,
+		`L 07/10/2019 - 23:00:40: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:00:40: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:01:40: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:02:00: Team "Red" triggered "pointcaptured" (cp "2") (cpname "#Badlands_cap_cp3") (numcappers "1") (player1 "rad<6><[U:1:57823119]><Red>") (position1 "99 97 7")`,
+		`L 07/10/2019 - 23:02:10: "rad<6><[U:1:57823119]><Red>" triggered "medic_death" against "Graba<3><[U:1:95947321]><Blue>" (healing "500") (ubercharge "1")`,
+		`L 07/10/2019 - 23:02:30: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:02:30: World triggered "Round_Length" (seconds "90.00")`,
+		`L 07/10/2019 - 23:03:00: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:03:10: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:03:20: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:03:25: "z/<14><[U:1:66656848]><Blue>" killed "rad<6><[U:1:57823119]><Red>" with "scattergun" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	})
+	rad, med, zed := s.Players[76561198018088847].SteamId, s.Players[76561198056213049].SteamId, s.Players[76561198026922576].SteamId
+	require.Equal(t, 1, len(s.Rounds))
+	var types []RoundEventType
+	for _, ev := range s.Rounds[0].Events {
+		assert.Equal(t, 1, ev.Round)
+		types = append(types, ev.Type)
+	}
+	assert.Equal(t, []RoundEventType{RoundEventFirstBlood, RoundEventCharge, RoundEventPause, RoundEventUnpause,
+		RoundEventPointCap, RoundEventMedicDeath, RoundEventDrop}, types)
+	events := s.Rounds[0].Events
+	assert.Equal(t, RoundEvent{Type: RoundEventFirstBlood, Time: 10 * time.Second, MatchTime: 10 * time.Second,
+		CreatedOn: time.Date(2019, 7, 10, 23, 0, 10, 0, time.UTC), Round: 1, Phase: PhaseActive, Team: RED, SteamId: rad, Victim: zed}, events[0])
+	assert.Equal(t, kritzkrieg, events[1].Medigun)
+	assert.Equal(t, med, events[1].SteamId)
+	assert.Equal(t, 2, events[4].Point)
+	assert.Equal(t, RED, events[4].Team)
+	assert.Equal(t, 70*time.Second, events[6].Time)
+	assert.Equal(t, rad, events[6].Killer)
+	assert.Equal(t, BLU, events[6].Team)
+
+	timeline := s.Timeline()
+	require.Equal(t, 10, len(timeline))
+	assert.Equal(t, RoundEventPause, timeline[7].Type)
+	assert.Equal(t, 0, timeline[7].Round)
+	assert.Equal(t, PhaseHumiliation, timeline[7].Phase)
+	assert.Equal(t, time.Duration(0), timeline[7].Time)
+	assert.Equal(t, 120*time.Second, timeline[7].MatchTime)
+	assert.Equal(t, RoundEvent{Type: RoundEventFirstBlood, Time: 5 * time.Second, MatchTime: 135 * time.Second,
+		CreatedOn: time.Date(2019, 7, 10, 23, 3, 25, 0, time.UTC), Round: 2, Phase: PhaseActive, Team: BLU, SteamId: zed, Victim: rad}, timeline[9])
+}
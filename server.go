@@ -0,0 +1,63 @@
+package logstf
+
+import (
+	"strings"
+)
+
+// Cvars with a special meaning for the summary
+const (
+	cvarHostname     = "hostname"
+	cvarRedTeamName  = "mp_tournament_redteamname"
+	cvarBlueTeamName = "mp_tournament_blueteamname"
+)
+
+func (s *LogSummary) serverCvar(name, value string) {
+	s.Cvars[name] = value
+	switch name {
+	case cvarHostname:
+		s.ServerName = value
+	case cvarRedTeamName:
+		s.TeamNames[RED] = value
+	case cvarBlueTeamName:
+		s.TeamNames[BLU] = value
+	}
+}
+
+// rconCommand records the configs executed and any cvars set over rcon. Multiple commands can
+// be sent at once separated by ;
+func (s *LogSummary) rconCommand(command string) {
+	for _, cmd := range strings.Split(command, ";") {
+		fields := strings.Fields(cmd)
+		if len(fields) < 2 {
+			continue
+		}
+		value := strings.Trim(strings.Join(fields[1:], " "), `"`)
+		switch fields[0] {
+		case "exec":
+			s.Configs = append(s.Configs, strings.TrimSuffix(value, ".cfg"))
+		case cvarHostname, cvarRedTeamName, cvarBlueTeamName:
+			s.serverCvar(fields[0], value)
+		}
+	}
+}
+
+// Config returns the last config executed, this is normally the league config the match
+// was played under eg. etf2l_6v6_5cp
+func (s *LogSummary) Config() string {
+	if len(s.Configs) == 0 {
+		return ""
+	}
+	return s.Configs[len(s.Configs)-1]
+}
+
+// copyServerInfo carries the server details over to the next match found in the same log
+func (s *LogSummary) copyServerInfo(from *LogSummary) {
+	s.ServerName = from.ServerName
+	s.Configs = append([]string(nil), from.Configs...)
+	for k, v := range from.Cvars {
+		s.Cvars[k] = v
+	}
+	for k, v := range from.TeamNames {
+		s.TeamNames[k] = v
+	}
+}
@@ -0,0 +1,60 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestServerInfo(t *testing.T) {
+	lines := []string{
+		`L 10/13/2019 - 20:00:00: Loading map "cp_process_final"`,
+		`L 10/13/2019 - 20:00:01: server cvars start`,
+		`L 10/13/2019 - 20:00:01: "mp_timelimit" = "30"`,
+		`L 10/13/2019 - 20:00:01: "hostname" = "serveme.tf #1234"`,
+		`L 10/13/2019 - 20:00:01: server cvars end`,
+		`L 10/13/2019 - 20:00:01: Started map "cp_process_final" (CRC "bb1e2a62d4e4cb8e2b4c63ec0d2b5ae7")`,
+		`L 10/13/2019 - 20:02:00: rcon from "1.2.3.4:27005": command "exec etf2l_6v6_5cp.cfg"`,
+		`L 10/13/2019 - 20:02:01: server_cvar: "mp_winlimit" "5"`,
+		`L 10/13/2019 - 20:02:01: server_cvar: "mp_tournament_blueteamname" "BLU"`,
+		`L 10/13/2019 - 20:02:30: rcon from "1.2.3.4:27005": command "mp_tournament_redteamname "Froyo Tech"; mp_tournament_restart"`,
+		`L 10/13/2019 - 20:04:59: Tournament mode started`,
+		`L 10/13/2019 - 20:04:59: Blue Team: Ascent`,
+		`L 10/13/2019 - 20:05:00: World triggered "Round_Start"`,
+		`L 10/13/2019 - 20:06:00: World triggered "Round_Win" (winner "Red")`,
+		`L 10/13/2019 - 20:06:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+	}
+	for _, line := range lines {
+		ev, errLex := ParseEvent(line)
+		evRx, errRx := parseEventRx(line)
+		assert.Equal(t, errRx, errLex, line)
+		assert.Equal(t, evRx, ev, line)
+		if ev != nil {
+			out, err := FormatEvent(ev)
+			require.NoError(t, err)
+			assert.Equal(t, line, out)
+		}
+	}
+
+	s := NewSummary()
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines, "\n"))))
+	assert.Equal(t, 0, s.Report.UnhandledCount())
+	assert.Equal(t, "cp_process_final", s.Map)
+	assert.Equal(t, "serveme.tf #1234", s.ServerName)
+	assert.Equal(t, []string{"etf2l_6v6_5cp"}, s.Configs)
+	assert.Equal(t, "etf2l_6v6_5cp", s.Config())
+	assert.Equal(t, "30", s.Cvars["mp_timelimit"])
+	assert.Equal(t, "5", s.Cvars["mp_winlimit"])
+	assert.Equal(t, map[Team]string{RED: "Froyo Tech", BLU: "Ascent"}, s.TeamNames)
+
+	// The details from before the restart carry over to the match
+	matches, err := SplitMatches(context.Background(), strings.NewReader(strings.Join(lines, "\n")), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "serveme.tf #1234", matches[0].ServerName)
+	assert.Equal(t, "etf2l_6v6_5cp", matches[0].Config())
+	assert.Equal(t, "5", matches[0].Cvars["mp_winlimit"])
+	assert.Equal(t, "Froyo Tech", matches[0].TeamNames[RED])
+}
@@ -0,0 +1,157 @@
+package logstf
+
+import (
+	"time"
+)
+
+// Session is a single stay on the server, from connecting until disconnecting. Players already
+// on the server when the log starts get a session from the first line they show up in.
+type Session struct {
+	Joined    time.Time
+	Validated time.Time
+	Entered   time.Time
+	Left      time.Time // Zero while still connected
+	Reason    string    // Disconnect reason
+	Connected bool      // The connect line was seen, false when the player was already on the server
+	Teams     []TeamChange
+}
+
+// TeamChange records a player moving to a team, including spectator
+type TeamChange struct {
+	Team Team
+	At   time.Time
+}
+
+// Active returns true until the player disconnects
+func (s *Session) Active() bool {
+	return s.Left.IsZero()
+}
+
+// session returns the latest session, nil when the player has not been seen yet
+func (p *Player) session() *Session {
+	if len(p.Sessions) == 0 {
+		return nil
+	}
+	return p.Sessions[len(p.Sessions)-1]
+}
+
+// Reconnects returns how many times the player came back after leaving
+func (p *Player) Reconnects() int {
+	if len(p.Sessions) == 0 {
+		return 0
+	}
+	return len(p.Sessions) - 1
+}
+
+// TimePlayed returns the time spent on a playing team in the phases that count towards the
+// stats. Warmup, humiliation, the time between rounds and pauses are not counted unless the
+// stats from warmup or humiliation are.
+func (p *Player) TimePlayed() time.Duration {
+	played := p.timePlayed
+	if !p.playingSince.IsZero() && p.summary != nil && p.summary.now.After(p.playingSince) {
+		played += p.summary.now.Sub(p.playingSince)
+	}
+	return played
+}
+
+// minutesPlayed is the divisor for the per minute stats. The match length is used when no
+// time was tracked for the player, eg. logs where the player never shows up on a team.
+func (p *Player) minutesPlayed() float64 {
+	if played := p.TimePlayed(); played > 0 {
+		return played.Minutes()
+	}
+	if p.summary == nil {
+		return 0
+	}
+	return p.summary.TotalLength().Minutes()
+}
+
+// perMinute returns value per minute played, zero when no time was played at all
+func (p *Player) perMinute(value int64) float64 {
+	minutes := p.minutesPlayed()
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(value) / minutes
+}
+
+// seen makes sure the player has an open session, starting one for players that were on the
+// server before the log started or that show up again without a connect line
+func (s *LogSummary) seen(player *Player) *Session {
+	if sess := player.session(); sess != nil && sess.Active() {
+		return sess
+	}
+	sess := &Session{Joined: s.now}
+	player.Sessions = append(player.Sessions, sess)
+	return sess
+}
+
+func (s *LogSummary) connected(player *Player, ts time.Time) {
+	sess := s.seen(player)
+	if sess.Connected || sess.Joined.Before(ts) {
+		// Connecting again without a disconnect line, eg. after a map change
+		sess.Left = ts
+		s.setPlaying(player, false, ts)
+		sess = s.seen(player)
+	}
+	sess.Connected = true
+}
+
+func (s *LogSummary) validated(player *Player, ts time.Time) {
+	s.seen(player).Validated = ts
+}
+
+func (s *LogSummary) entered(player *Player, ts time.Time) {
+	s.seen(player).Entered = ts
+}
+
+func (s *LogSummary) disconnected(player *Player, ts time.Time, reason string) {
+	sess := s.seen(player)
+	sess.Left = ts
+	sess.Reason = reason
+	s.setPlaying(player, false, ts)
+}
+
+// teamChanged records the team on the session and starts or stops the play clock
+func (s *LogSummary) teamChanged(player *Player, team Team, ts time.Time) {
+	sess := s.seen(player)
+	if n := len(sess.Teams); n == 0 || sess.Teams[n-1].Team != team {
+		sess.Teams = append(sess.Teams, TeamChange{Team: team, At: ts})
+	}
+	s.setPlaying(player, team == RED || team == BLU, ts)
+}
+
+// setPlaying updates whether the player is on a playing team
+func (s *LogSummary) setPlaying(player *Player, playing bool, ts time.Time) {
+	player.playing = playing
+	s.settle(player, ts)
+}
+
+// settle adds the time played up to ts and restarts the clock if the player is still playing
+// on a team. It must be called after anything changes that affects either.
+func (s *LogSummary) settle(player *Player, ts time.Time) {
+	if !player.playingSince.IsZero() {
+		if ts.After(player.playingSince) {
+			player.timePlayed += ts.Sub(player.playingSince)
+			if cs := player.classStats(); cs != nil {
+				cs.TotalTime += ts.Sub(player.playingSince)
+			}
+		}
+		player.playingSince = time.Time{}
+	}
+	if player.playing && s.isLive() {
+		player.playingSince = ts
+	}
+}
+
+// settleAll is called when the match phase changes or the game is paused
+func (s *LogSummary) settleAll(ts time.Time) {
+	for _, p := range s.Players {
+		s.settle(p, ts)
+	}
+}
+
+// isLive returns true while the game is not paused and the stats are counted
+func (s *LogSummary) isLive() bool {
+	return !s.paused && s.countsStats()
+}
@@ -0,0 +1,94 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlayerSessions(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+		`L 07/10/2019 - 23:02:00: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "1000") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:05:00: "rad<6><[U:1:57823119]><Red>" disconnected (reason "rad timed out")`,
+		// Substitute comes in for rad
+		`L 07/10/2019 - 23:05:10: "Graba<3><[U:1:95947321]><>" connected, address "1.2.3.4:27005"`,
+		`L 07/10/2019 - 23:05:11: "Graba<3><[U:1:95947321]><>" STEAM USERID validated`,
+		`L 07/10/2019 - 23:05:20: "Graba<3><[U:1:95947321]><>" entered the game`,
+		`L 07/10/2019 - 23:05:30: "Graba<3><[U:1:95947321]><Unassigned>" joined team "Red"`,
+		`L 07/10/2019 - 23:05:30: "Graba<3><[U:1:95947321]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:06:00: "Graba<3><[U:1:95947321]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "1000") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:07:00: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:08:00: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:09:00: "rad<15><[U:1:57823119]><>" connected, address "1.2.3.5:27005"`,
+		`L 07/10/2019 - 23:09:30: "rad<15><[U:1:57823119]><Unassigned>" joined team "Spectator"`,
+		`L 07/10/2019 - 23:10:30: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:10:30: World triggered "Round_Length" (seconds "570.00")`,
+	}
+	s := NewSummary()
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines, "\n"))))
+
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	require.Equal(t, 2, len(rad.Sessions))
+	assert.Equal(t, 1, rad.Reconnects())
+	assert.False(t, rad.Sessions[0].Connected)
+	assert.Equal(t, "rad timed out", rad.Sessions[0].Reason)
+	assert.True(t, rad.Sessions[1].Active())
+	assert.Equal(t, []TeamChange{{Team: SPEC, At: time.Date(2019, 7, 10, 23, 9, 30, 0, time.UTC)}}, rad.Sessions[1].Teams)
+	assert.Equal(t, 5*time.Minute, rad.TimePlayed())
+	assert.Equal(t, 200.0, rad.DamagePerMin())
+
+	sub := s.Players[76561198056213049]
+	require.NotNil(t, sub)
+	require.Equal(t, 1, len(sub.Sessions))
+	assert.True(t, sub.Sessions[0].Connected)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 5, 10, 0, time.UTC), sub.Sessions[0].Joined)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 5, 11, 0, time.UTC), sub.Sessions[0].Validated)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 5, 20, 0, time.UTC), sub.Sessions[0].Entered)
+	assert.Equal(t, 4*time.Minute, sub.TimePlayed())
+	assert.Equal(t, 250.0, sub.DamagePerMin())
+
+	scout := s.Players[76561198026922576]
+	require.NotNil(t, scout)
+	assert.Equal(t, 9*time.Minute+30*time.Second, scout.TimePlayed())
+}
+
+func TestTimePlayedRounds(t *testing.T) {
+	lines := []string{
+		`L 07/10/2019 - 22:59:00: Log file started (file "logs/L0710000.log") (game "/home/tf2/tf") (version "5409153")`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Unassigned>" joined team "Red"`,
+		`L 07/10/2019 - 23:01:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:03:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:03:00: World triggered "Round_Length" (seconds "120.00")`,
+		`L 07/10/2019 - 23:04:00: World triggered "Game_Paused"`,
+		`L 07/10/2019 - 23:05:00: World triggered "Game_Unpaused"`,
+		`L 07/10/2019 - 23:06:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:08:00: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:08:00: World triggered "Round_Length" (seconds "120.00")`,
+		`L 07/10/2019 - 23:08:10: World triggered "Game_Over" reason "Reached Win Limit"`,
+		`L 07/10/2019 - 23:09:00: "z/<14><[U:1:66656848]><Blue>" say "gg"`,
+	}
+	s := applyLines(t, lines)
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	assert.Equal(t, 4*time.Minute, rad.TimePlayed())
+
+	// Warmup and humiliation count when their stats do
+	s = NewSummary()
+	s.CountWarmup = true
+	s.CountHumiliation = true
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(lines, "\n"))))
+	assert.Equal(t, 7*time.Minute+10*time.Second, s.Players[76561198018088847].TimePlayed())
+
+	// Nothing played and no match length to fall back to
+	p := NewSummary().getPlayer(76561198018088847)
+	p.Damage = 100
+	assert.Equal(t, 0.0, p.DamagePerMin())
+	assert.Equal(t, 0.0, p.DamageTakenPerMin())
+}
@@ -0,0 +1,174 @@
+package logstf
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrScoreMismatch is returned when the final score logged by the server does not match the
+// rounds won, eg. when the log starts after the first round
+var ErrScoreMismatch = errors.New("final score does not match rounds won")
+
+// MatchPhase is the state of the match an event happened in
+type MatchPhase int
+
+const (
+	PhaseUnknown MatchPhase = iota
+	PhaseWarmup
+	PhaseActive
+	PhaseHumiliation
+	PhasePaused
+	PhaseOvertime
+	PhaseGameOver
+)
+
+func (p MatchPhase) String() string {
+	switch p {
+	case PhaseWarmup:
+		return "warmup"
+	case PhaseActive:
+		return "active"
+	case PhaseHumiliation:
+		return "humiliation"
+	case PhasePaused:
+		return "paused"
+	case PhaseOvertime:
+		return "overtime"
+	case PhaseGameOver:
+		return "game_over"
+	default:
+		return "unknown"
+	}
+}
+
+// Phase returns the current phase of the match
+func (s *LogSummary) Phase() MatchPhase {
+	return s.phase
+}
+
+// setPhase moves the match to the next phase. While paused the phase to return to is updated
+// instead so the game does not resume in the wrong state.
+func (s *LogSummary) setPhase(phase MatchPhase) {
+	if s.phase == PhasePaused && phase != PhasePaused {
+		s.phaseBeforePause = phase
+		return
+	}
+	if s.phase != phase {
+		s.phase = phase
+		s.settleAll(s.now)
+	}
+}
+
+func (s *LogSummary) pausePhase() {
+	if s.phase == PhasePaused {
+		return
+	}
+	s.phaseBeforePause = s.phase
+	s.phase = PhasePaused
+}
+
+func (s *LogSummary) unpausePhase() {
+	if s.phase != PhasePaused {
+		return
+	}
+	s.phase = s.phaseBeforePause
+}
+
+// tagPhase records the phase the event was applied in and its game time on the event
+func (s *LogSummary) tagPhase(event Event) {
+	base := event.eventBase()
+	base.Phase = s.phase
+	base.GameTime = s.GameTime(base.CreatedOn)
+}
+
+// countsStats returns true when player stats should be recorded in the current phase. Warmup
+// and humiliation are only counted when enabled with CountWarmup and CountHumiliation. Until
+// a pregame marker or round start is seen the log is assumed to have started mid match, this
+// also keeps the stats of logs without any round markers.
+func (s *LogSummary) countsStats() bool {
+	phase := s.phase
+	if phase == PhasePaused {
+		phase = s.phaseBeforePause
+	}
+	switch phase {
+	case PhaseUnknown, PhaseActive, PhaseOvertime:
+		return true
+	case PhaseWarmup:
+		return s.CountWarmup
+	case PhaseHumiliation:
+		return s.CountHumiliation
+	default:
+		return false
+	}
+}
+
+// isStatEvent returns false for the events that keep track of the players, the match and the
+// server, everything else adds to the stats and is dropped in phases that are not counted
+func isStatEvent(event Event) bool {
+	switch event.(type) {
+	case *ConnectedEvent, *DisconnectedEvent, *ValidatedEvent, *EnteredEvent, *JoinedTeamEvent,
+		*ChangeClassEvent, *SpawnedAsEvent, *SayEvent, *RoundOvertimeEvent, *RoundStartEvent,
+		*RoundWinEvent, *RoundLengthEvent, *TeamScoreEvent, *GameOverEvent, *PausedEvent,
+		*UnpausedEvent, *LogStartedEvent, *LoadingMapEvent, *StartedMapEvent, *ServerCvarEvent,
+		*TournamentStartedEvent, *TeamNameEvent, *RconEvent:
+		return false
+	default:
+		return true
+	}
+}
+
+// pregame moves the match to warmup when the log, map or tournament starts before the first
+// round
+func (s *LogSummary) pregame() {
+	if !s.roundStarted {
+		s.setPhase(PhaseWarmup)
+	}
+}
+
+func (s *LogSummary) overtime() {
+	if s.roundStarted {
+		s.setPhase(PhaseOvertime)
+	}
+}
+
+func (s *LogSummary) gameOver() {
+	s.setPhase(PhaseGameOver)
+}
+
+// teamScore records the final score of the team, the current score lines are only informative
+// as the score is tallied from the round wins
+func (s *LogSummary) teamScore(team Team, score int, final bool) {
+	if !final || (team != RED && team != BLU) {
+		return
+	}
+	s.FinalScore[team] = score
+	if err := s.verifyTeamScore(team); err != nil {
+		s.eventError(err)
+	}
+}
+
+// VerifyScore checks the final score lines against the rounds won. Logs without final score
+// lines, eg. matches that were cut short, are not checked.
+func (s *LogSummary) VerifyScore() error {
+	for _, team := range []Team{RED, BLU} {
+		if err := s.verifyTeamScore(team); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LogSummary) verifyTeamScore(team Team) error {
+	final, found := s.FinalScore[team]
+	if !found {
+		return nil
+	}
+	won := s.ScoreRed
+	if team == BLU {
+		won = s.ScoreBlu
+	}
+	if final != won {
+		return fmt.Errorf("%w: %s final score %d, rounds won %d", ErrScoreMismatch, teamName(team, "Spectator"), final, won)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package logstf
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var stateTestLines = []string{
+	`L 07/10/2019 - 22:59:00: Log file started (file "logs/L0710000.log") (game "/home/tf2/tf") (version "5409153")`,
+	`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+	`L 07/10/2019 - 23:00:00: "z/<14><[U:1:66656848]><Blue>" spawned as "Scout"`,
+	`L 07/10/2019 - 23:00:05: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:01:00: World triggered "Round_Start"`,
+	`L 07/10/2019 - 23:01:10: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "100") (weapon "quake_rl")`,
+	`L 07/10/2019 - 23:02:00: World triggered "Round_Overtime"`,
+	`L 07/10/2019 - 23:02:10: World triggered "Game_Paused"`,
+	`L 07/10/2019 - 23:02:20: World triggered "Game_Unpaused"`,
+	`L 07/10/2019 - 23:02:30: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:02:40: World triggered "Round_Win" (winner "Red")`,
+	`L 07/10/2019 - 23:02:40: World triggered "Round_Length" (seconds "90.00")`,
+	`L 07/10/2019 - 23:02:45: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+	`L 07/10/2019 - 23:02:50: World triggered "Game_Over" reason "Reached Win Limit"`,
+	`L 07/10/2019 - 23:02:50: Team "Red" final score "1" with "1" players`,
+	`L 07/10/2019 - 23:02:50: Team "Blue" final score "0" with "1" players`,
+}
+
+func TestMatchPhases(t *testing.T) {
+	s := NewSummary()
+	var phases []MatchPhase
+	for _, line := range stateTestLines {
+		ev, err := ParseEvent(line)
+		require.NoError(t, err)
+		s.ApplyEvent(ev)
+		phases = append(phases, ev.eventBase().Phase)
+	}
+	assert.Equal(t, []MatchPhase{PhaseWarmup, PhaseWarmup, PhaseWarmup, PhaseWarmup, PhaseActive, PhaseActive, PhaseOvertime,
+		PhasePaused, PhaseOvertime, PhaseOvertime, PhaseHumiliation, PhaseHumiliation, PhaseHumiliation,
+		PhaseGameOver, PhaseGameOver, PhaseGameOver}, phases)
+	assert.Equal(t, PhaseGameOver, s.Phase())
+	assert.Equal(t, map[Team]int{RED: 1, BLU: 0}, s.FinalScore)
+	assert.NoError(t, s.VerifyScore())
+
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	assert.Equal(t, 1, len(rad.Kills))
+	assert.Equal(t, int64(100), rad.Damage)
+}
+
+func TestMatchPhaseCounting(t *testing.T) {
+	s := NewSummary()
+	s.CountWarmup = true
+	s.CountHumiliation = true
+	for _, line := range stateTestLines {
+		ev, err := ParseEvent(line)
+		require.NoError(t, err)
+		s.ApplyEvent(ev)
+	}
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	require.Equal(t, 3, len(rad.Kills))
+	// Humiliation counts towards the round that was just won
+	assert.Equal(t, 2, s.Rounds[0].KillsRed)
+	var rounds []int
+	for _, k := range rad.Kills {
+		rounds = append(rounds, k.Round)
+	}
+	assert.Equal(t, []int{0, 1, 1}, rounds)
+}
+
+func TestVerifyScore(t *testing.T) {
+	// The log starts after red already won a round
+	lines := append([]string{}, stateTestLines[4:14]...)
+	s := applyLines(t, append(lines, `L 07/10/2019 - 23:02:50: Team "Red" final score "2" with "1" players`))
+	err := s.VerifyScore()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrScoreMismatch))
+}
+
+func TestMatchPhaseWithoutRounds(t *testing.T) {
+	// Logs without any round markers count everything
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:05: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:05: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "100") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:05: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:01:00: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "50") (weapon "quake_rl")`,
+	})
+	assert.Equal(t, PhaseUnknown, s.Phase())
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	assert.Equal(t, 1, len(rad.Kills))
+	assert.Equal(t, int64(150), rad.Damage)
+	assert.Equal(t, 1, rad.Weapons["quake_rl"].Shots)
+	assert.Equal(t, 150.0, rad.DamagePerMin())
+}
+
+func TestMatchPhaseGatesAllStats(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 22:59:00: Log file started (file "logs/L0710000.log") (game "/home/tf2/tf") (version "5409153")`,
+		`L 07/10/2019 - 23:00:00: "Graba<3><[U:1:95947321]><Blue>" spawned as "Medic"`,
+		`L 07/10/2019 - 23:00:00: "rad<6><[U:1:57823119]><Red>" spawned as "Soldier"`,
+		`L 07/10/2019 - 23:00:10: "Graba<3><[U:1:95947321]><Blue>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "100")`,
+		`L 07/10/2019 - 23:00:10: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl") (airshot "1")`,
+		`L 07/10/2019 - 23:00:10: "rad<6><[U:1:57823119]><Red>" triggered "domination" against "z/<14><[U:1:66656848]><Blue>"`,
+		`L 07/10/2019 - 23:00:10: "rad<6><[U:1:57823119]><Red>" picked up item "medkit_small" (healing "20")`,
+		`L 07/10/2019 - 23:00:10: "Graba<3><[U:1:95947321]><Blue>" triggered "captureblocked" (cp "2") (cpname "#Badlands_cap_cp3") (position "1 2 3")`,
+		`L 07/10/2019 - 23:01:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:01:10: "Graba<3><[U:1:95947321]><Blue>" triggered "healed" against "z/<14><[U:1:66656848]><Blue>" (healing "50")`,
+		`L 07/10/2019 - 23:01:20: World triggered "Round_Win" (winner "Red")`,
+		`L 07/10/2019 - 23:01:30: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl") (airshot "1")`,
+	})
+	med, rad := s.Players[76561198056213049], s.Players[76561198018088847]
+	require.NotNil(t, med)
+	require.NotNil(t, rad)
+	assert.Equal(t, int64(50), med.HealingSum.Healing)
+	assert.Equal(t, 0, med.Defenses)
+	assert.Equal(t, int64(0), rad.Damage)
+	assert.Equal(t, 0, rad.AirShots)
+	assert.Equal(t, 0, rad.Dominations)
+	assert.Equal(t, 0, rad.SmallMedPacks)
+	assert.Equal(t, int64(0), rad.HealsReceived)
+}
@@ -0,0 +1,129 @@
+package logstf
+
+import (
+	"github.com/leighmacdonald/steamid"
+	"sort"
+	"time"
+)
+
+// StreakOptions controls which kill streaks and multi kills are reported
+type StreakOptions struct {
+	Threshold       int           // Minimum kills without dying to count as a streak
+	MultiKillWindow time.Duration // Kills must all happen within this long of the first
+	MultiKillSize   int           // Minimum kills inside the window to count as a multi kill
+}
+
+func DefaultStreakOptions() StreakOptions {
+	return StreakOptions{
+		Threshold:       3,
+		MultiKillWindow: 5 * time.Second,
+		MultiKillSize:   3,
+	}
+}
+
+// KillStreak is a run of kills by a player within a round without dying
+type KillStreak struct {
+	SteamId   steamid.SID64
+	Kills     int
+	Round     int
+	StartedOn time.Time
+	EndedOn   time.Time
+}
+
+// MultiKill is a burst of kills in quick succession, eg. a triple kill
+type MultiKill struct {
+	SteamId   steamid.SID64
+	Kills     int
+	Round     int
+	StartedOn time.Time
+	EndedOn   time.Time
+}
+
+// KillStreaks returns the streaks of every player at or above Streaks.Threshold, ordered by
+// when they started. A streak ends when the player dies or the round ends.
+func (s *LogSummary) KillStreaks() []KillStreak {
+	var streaks []KillStreak
+	for _, p := range s.Players {
+		for _, run := range p.killRuns() {
+			if len(run) >= s.Streaks.Threshold {
+				streaks = append(streaks, KillStreak{
+					SteamId:   p.SteamId,
+					Kills:     len(run),
+					Round:     run[0].Round,
+					StartedOn: run[0].CreatedOn,
+					EndedOn:   run[len(run)-1].CreatedOn,
+				})
+			}
+		}
+	}
+	sort.Slice(streaks, func(i, j int) bool {
+		if !streaks[i].StartedOn.Equal(streaks[j].StartedOn) {
+			return streaks[i].StartedOn.Before(streaks[j].StartedOn)
+		}
+		return streaks[i].SteamId < streaks[j].SteamId
+	})
+	return streaks
+}
+
+// MultiKills returns the bursts of at least Streaks.MultiKillSize kills within
+// Streaks.MultiKillWindow, ordered by when they started. Each kill is only counted in one burst.
+func (s *LogSummary) MultiKills() []MultiKill {
+	var multi []MultiKill
+	for _, p := range s.Players {
+		for _, run := range p.killRuns() {
+			for start := 0; start < len(run); {
+				end := start + 1
+				for end < len(run) && run[end].CreatedOn.Sub(run[start].CreatedOn) <= s.Streaks.MultiKillWindow {
+					end++
+				}
+				if end-start >= s.Streaks.MultiKillSize {
+					multi = append(multi, MultiKill{
+						SteamId:   p.SteamId,
+						Kills:     end - start,
+						Round:     run[start].Round,
+						StartedOn: run[start].CreatedOn,
+						EndedOn:   run[end-1].CreatedOn,
+					})
+				}
+				start = end
+			}
+		}
+	}
+	sort.Slice(multi, func(i, j int) bool {
+		if !multi[i].StartedOn.Equal(multi[j].StartedOn) {
+			return multi[i].StartedOn.Before(multi[j].StartedOn)
+		}
+		return multi[i].SteamId < multi[j].SteamId
+	})
+	return multi
+}
+
+// killRuns splits the kills of the player into runs between deaths and round changes. A kill
+// and death in the same second counts the kill first.
+func (p *Player) killRuns() [][]Kill {
+	kills := append([]Kill(nil), p.Kills...)
+	deaths := append([]Kill(nil), p.Deaths...)
+	sort.SliceStable(kills, func(i, j int) bool { return kills[i].CreatedOn.Before(kills[j].CreatedOn) })
+	sort.SliceStable(deaths, func(i, j int) bool { return deaths[i].CreatedOn.Before(deaths[j].CreatedOn) })
+	var (
+		runs [][]Kill
+		run  []Kill
+		d    int
+	)
+	for _, k := range kills {
+		died := false
+		for d < len(deaths) && deaths[d].CreatedOn.Before(k.CreatedOn) {
+			died = true
+			d++
+		}
+		if len(run) > 0 && (died || run[0].Round != k.Round) {
+			runs = append(runs, run)
+			run = nil
+		}
+		run = append(run, k)
+	}
+	if len(run) > 0 {
+		runs = append(runs, run)
+	}
+	return runs
+}
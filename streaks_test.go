@@ -0,0 +1,33 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestKillStreaks(t *testing.T) {
+	start := time.Date(2019, 7, 10, 23, 0, 0, 0, time.UTC)
+	at := func(sec int, round int) Kill {
+		return Kill{CreatedOn: start.Add(time.Duration(sec) * time.Second), Round: round}
+	}
+	s := NewSummary()
+	p := s.getPlayer(76561198018088847)
+	// Triple kill, then a fourth kill, death, two kills, round change, three kills
+	p.Kills = []Kill{at(10, 1), at(12, 1), at(14, 1), at(30, 1), at(50, 1), at(51, 1),
+		at(100, 2), at(200, 2), at(300, 2)}
+	p.Deaths = []Kill{at(30, 1), at(40, 1)}
+
+	assert.Equal(t, []KillStreak{
+		{SteamId: p.SteamId, Kills: 4, Round: 1, StartedOn: start.Add(10 * time.Second), EndedOn: start.Add(30 * time.Second)},
+		{SteamId: p.SteamId, Kills: 3, Round: 2, StartedOn: start.Add(100 * time.Second), EndedOn: start.Add(300 * time.Second)},
+	}, s.KillStreaks())
+	assert.Equal(t, []MultiKill{
+		{SteamId: p.SteamId, Kills: 3, Round: 1, StartedOn: start.Add(10 * time.Second), EndedOn: start.Add(14 * time.Second)},
+	}, s.MultiKills())
+
+	s.Streaks.Threshold = 2
+	s.Streaks.MultiKillSize = 2
+	assert.Equal(t, 3, len(s.KillStreaks()))
+	assert.Equal(t, 2, len(s.MultiKills()))
+}
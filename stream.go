@@ -0,0 +1,138 @@
+package logstf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineSize is the longest line the Parser will accept
+const maxLineSize = 1024 * 1024
+
+// LineError is a failure to parse a specific line of the input
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Parser reads events from a stream of log lines without buffering the whole input. It
+// is used like a bufio.Scanner:
+//
+//	p := NewParser(ctx, os.Stdin)
+//	for p.Next() {
+//		if ev := p.Event(); ev != nil {
+//			...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+//
+// Cancellation is checked between lines, a Read call blocked on the underlying reader
+// will not be interrupted.
+type Parser struct {
+	ctx      context.Context
+	scanner  *bufio.Scanner
+	opts     ParseOptions
+	timeline timeline
+	line     int
+	text     string
+	event    Event
+	lineErr  *LineError
+	err      error
+}
+
+func NewParser(ctx context.Context, r io.Reader) *Parser {
+	return NewParserWithOptions(ctx, r, ParseOptions{})
+}
+
+// NewParserWithOptions creates a Parser using the date layout and timezone of opts. Event
+// timestamps are kept in order across midnight and DST changes.
+func NewParserWithOptions(ctx context.Context, r io.Reader, opts ParseOptions) *Parser {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Parser{ctx: ctx, scanner: scanner, opts: opts}
+}
+
+// Next advances to the next non empty line. It returns false once the input is exhausted, the
+// context is cancelled or reading fails.
+func (p *Parser) Next() bool {
+	p.event, p.lineErr, p.text = nil, nil, ""
+	if p.err != nil {
+		return false
+	}
+	for {
+		select {
+		case <-p.ctx.Done():
+			p.err = p.ctx.Err()
+			return false
+		default:
+		}
+		if !p.scanner.Scan() {
+			p.err = p.scanner.Err()
+			return false
+		}
+		p.line++
+		text := strings.TrimRight(p.scanner.Text(), "\r\n")
+		if text == "" {
+			continue
+		}
+		p.text = text
+		ev, err := p.timeline.parse(text, p.opts)
+		if err != nil {
+			p.lineErr = &LineError{Line: p.line, Text: text, Err: err}
+		}
+		p.event = ev
+		return true
+	}
+}
+
+// Event returns the event for the current line, nil if it did not parse. An event may still be
+// returned alongside a LineErr when only optional fields failed to parse.
+func (p *Parser) Event() Event {
+	return p.event
+}
+
+// LineErr returns the reason the current line failed to parse, nil when it parsed cleanly
+func (p *Parser) LineErr() *LineError {
+	return p.lineErr
+}
+
+// Line returns the 1 based line number of the current line
+func (p *Parser) Line() int {
+	return p.line
+}
+
+// Text returns the raw text of the current line
+func (p *Parser) Text() string {
+	return p.text
+}
+
+// Err returns the error that stopped the parser, if any. A fully consumed input returns nil.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// ApplyReader streams all of the lines from r into the summary. In Strict mode it stops at the
+// first line that fails to parse.
+func (s *LogSummary) ApplyReader(ctx context.Context, r io.Reader) error {
+	p := NewParserWithOptions(ctx, r, s.Options)
+	first := s.lineNum
+	for p.Next() {
+		var parseErr error
+		if lineErr := p.LineErr(); lineErr != nil {
+			parseErr = lineErr.Err
+		}
+		if err := s.applyParsed(first+p.Line(), p.Text(), p.Event(), parseErr); err != nil {
+			return err
+		}
+	}
+	return p.Err()
+}
@@ -0,0 +1,62 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	input := strings.Join([]string{
+		`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`,
+		``,
+		`L 07/10/2019 - 23:28:01: not a real line`,
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")` + "\r",
+	}, "\n")
+	p := NewParser(context.Background(), strings.NewReader(input))
+	require.True(t, p.Next())
+	assert.Equal(t, 1, p.Line())
+	assert.IsType(t, &RoundStartEvent{}, p.Event())
+	assert.Nil(t, p.LineErr())
+
+	require.True(t, p.Next())
+	assert.Equal(t, 3, p.Line())
+	assert.Nil(t, p.Event())
+	require.NotNil(t, p.LineErr())
+	assert.Equal(t, ErrUnhandledLine, p.LineErr().Err)
+	assert.Equal(t, "line 3: unhandled log line", p.LineErr().Error())
+
+	require.True(t, p.Next())
+	assert.Equal(t, 4, p.Line())
+	assert.IsType(t, &ShotFiredEvent{}, p.Event())
+
+	assert.False(t, p.Next())
+	assert.NoError(t, p.Err())
+}
+
+func TestParserCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for i := 0; i < 10; i++ {
+			_, _ = pw.Write([]byte(`L 07/10/2019 - 23:28:00: World triggered "Round_Start"` + "\n"))
+		}
+	}()
+	p := NewParser(ctx, pr)
+	require.True(t, p.Next())
+	cancel()
+	assert.False(t, p.Next())
+	assert.Equal(t, context.Canceled, p.Err())
+	_ = pw.Close()
+}
+
+func TestApplyReader(t *testing.T) {
+	s := NewSummary()
+	require.NoError(t, s.ApplyReader(context.Background(), strings.NewReader(strings.Join(testMatchLines, "\n"))))
+	assert.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, 1, s.ScoreRed)
+	assert.Equal(t, 4, len(s.Players))
+}
@@ -3,7 +3,7 @@ package logstf
 
 import (
 	"archive/zip"
-	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,7 +13,6 @@ import (
 	"os"
 	"path"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 )
@@ -23,15 +22,19 @@ type HealingSummary struct {
 	Charges              map[Medigun]int
 	ChargeLengths        []float64
 	Drops                int
-	AvgTimeToBuild       int
-	AvgTimeBeforeUsing   int
+	AvgTimeToBuild       int // Seconds from spawning or emptying the last charge to charge ready
+	AvgTimeBeforeUsing   int // Seconds from charge ready to deploying it
 	NearFullChargeDeaths int
-	DeathsAfterCharge    int
+	DeathsAfterCharge    int // Deaths within deathAfterChargeWindow of the charge running out
 	MajorAdvantagesLost  int
-	BiggestAdvantageLost int
+	BiggestAdvantageLost int // Seconds of the largest uber advantage lost
 	timesUntilHeal       []time.Duration
+	buildTimes           []time.Duration
+	useTimes             []time.Duration
 	Targets              map[*Player]int64
 	lastEmptyUber        time.Time
+	buildStart           time.Time // When the current charge started building, zero while dead
+	chargeReadyAt        time.Time // When the current charge was ready, zero when not ready
 }
 
 func (h *HealingSummary) Table(name string) string {
@@ -43,13 +46,13 @@ func (h *HealingSummary) Table(name string) string {
 	}
 	dt = append(dt, []string{"Charges", strings.Join(gs, ", ")})
 	dt = append(dt, []string{"Drops", fmt.Sprintf("%d", h.Drops)})
-	dt = append(dt, []string{"Avg. Build Time", fmt.Sprintf("%d", 0)})
-	dt = append(dt, []string{"Avg. Time To Use", fmt.Sprintf("%d", 0)})
+	dt = append(dt, []string{"Avg. Build Time", fmt.Sprintf("%ds", h.AvgTimeToBuild)})
+	dt = append(dt, []string{"Avg. Time To Use", fmt.Sprintf("%ds", h.AvgTimeBeforeUsing)})
 	dt = append(dt, []string{"Near Full Deaths", fmt.Sprintf("%d", h.NearFullChargeDeaths)})
-	dt = append(dt, []string{"Avg Uber Len.", fmt.Sprintf("%d", 0)})
-	dt = append(dt, []string{"Deaths After Charge", fmt.Sprintf("%d", 0)})
+	dt = append(dt, []string{"Avg Uber Len.", fmt.Sprintf("%.1fs", h.AvgUberLen())})
+	dt = append(dt, []string{"Deaths After Charge", fmt.Sprintf("%d", h.DeathsAfterCharge)})
 	dt = append(dt, []string{"Maj. Adv. Lost", fmt.Sprintf("%d", h.MajorAdvantagesLost)})
-	dt = append(dt, []string{"Biggest Adv. Lost", fmt.Sprintf("%d", 0)})
+	dt = append(dt, []string{"Biggest Adv. Lost", fmt.Sprintf("%ds", h.BiggestAdvantageLost)})
 	dt = append(dt, []string{"Heal Targets", ""})
 
 	for _, p := range sortPlayersByHealing(h.Targets) {
@@ -142,37 +145,63 @@ type Kill struct {
 	VPOS      Position
 	Victim    steamid.SID64
 	CreatedOn time.Time
+	Weapon    string
+	Round     int
 }
 
 // Player represents a player on the server. The base properties are global across the
 // match.
 type Player struct {
-	Name           string
-	SteamId        steamid.SID64
-	Team           Team
-	Kills          []Kill
-	Deaths         []Kill
-	Assists        int
-	Revenges       int
-	Dominations    int
-	Dominated      int
-	Healed         int64 // self healing, not medic healing
-	Damage         int64
-	DamageTaken    int64
-	SmallMedPacks  int
-	MediumMedPacks int
-	FullMedPacks   int
-	ShotsFired     int
-	ShotsHit       int
-	BackStabs      int
-	HeadShots      int
-	AirShots       int
-	Captures       int
-	Defenses       int
-	Classes        map[PlayerClass]classStats // Classes we have played
-	HealingSum     *HealingSummary            // Medic players will get a healing summary
-	CurrentClass   PlayerClass
-	summary        *LogSummary // Keep reference to get the match times for per min calc
+	Name             string
+	SteamId          steamid.SID64
+	IsBot            bool // SteamId is generated from the name, see BotSteamID
+	Team             Team
+	Kills            []Kill
+	Deaths           []Kill
+	Assists          int
+	Revenges         int
+	Dominations      int
+	Dominated        int
+	Healed           int64 // self healing, not medic healing
+	Damage           int64
+	DamageTaken      int64
+	SmallMedPacks    int
+	MediumMedPacks   int
+	FullMedPacks     int
+	ShotsFired       int
+	ShotsHit         int
+	BackStabs        int
+	HeadShots        int
+	AirShots         int
+	Captures         int
+	Defenses         int
+	IntelPickups     int
+	IntelDrops       int
+	IntelCaptures    int
+	IntelDefenses    int
+	IntelCarryTime   time.Duration
+	Classes          map[PlayerClass]*classStats // Stats for each class we have played
+	Weapons          map[string]*weaponStats     // Stats for each weapon used, keyed by the log name
+	ClassKills       ClassMatrix                 // Kills by our class and the victims class
+	ClassDeaths      ClassMatrix                 // Deaths by our class and the killers class
+	ClassKillAssists ClassMatrix                 // Assists by our class and the victims class
+	HealsReceived    int64                       // Healing received from other players, the logs.tf hr stat
+	HealsBySource    map[HealSource]int64        // All healing received including packs and lifesteal
+	HealsByMedic     map[steamid.SID64]int64     // Healing received from each medic
+	Buildings        *BuildingStats
+	Extinguishes     []Extinguish    // Burning teammates we put out
+	Extinguished     int             // Times we were put out by a teammate
+	Deflects         map[string]int  // Projectiles reflected by projectile type
+	PlayerDeflects   int             // Players airblasted
+	ReflectKills     map[string]int  // Kills with reflected projectiles by deflect_ weapon
+	HealingSum       *HealingSummary // Medic players will get a healing summary
+	CurrentClass     PlayerClass
+	Sessions         []*Session  // Each stay on the server, more than one when reconnecting
+	summary          *LogSummary // Keep reference to get the match times for per min calc
+	carryStart       time.Time   // When the intel was picked up, zero when not carrying
+	playing          bool        // On RED or BLU and connected
+	playingSince     time.Time   // Start of the current stretch of time played, zero when not counting
+	timePlayed       time.Duration
 }
 
 type classStats struct {
@@ -183,14 +212,32 @@ type classStats struct {
 	TotalTime time.Duration
 }
 
+// classStats returns the stats for the class the player is currently on, nil before the first
+// spawn
+func (p *Player) classStats() *classStats {
+	return p.Classes[p.CurrentClass]
+}
+
 func NewPlayer(sum *LogSummary) *Player {
-	return &Player{summary: sum, Classes: make(map[PlayerClass]classStats)}
+	return &Player{
+		summary:          sum,
+		Classes:          make(map[PlayerClass]*classStats),
+		Weapons:          make(map[string]*weaponStats),
+		ClassKills:       make(ClassMatrix),
+		ClassDeaths:      make(ClassMatrix),
+		ClassKillAssists: make(ClassMatrix),
+		HealsBySource:    make(map[HealSource]int64),
+		HealsByMedic:     make(map[steamid.SID64]int64),
+		Buildings:        NewBuildingStats(),
+		Deflects:         make(map[string]int),
+		ReflectKills:     make(map[string]int),
+	}
 }
 
 func (p *Player) AddClass(cls PlayerClass) {
 	_, found := p.Classes[cls]
 	if !found {
-		p.Classes[cls] = classStats{}
+		p.Classes[cls] = &classStats{}
 		if cls == medic {
 			p.HealingSum = NewHealingSummary()
 		}
@@ -198,12 +245,14 @@ func (p *Player) AddClass(cls PlayerClass) {
 	p.CurrentClass = cls
 }
 
+// DamagePerMin uses the players own time played so substitutes are not measured against the
+// full match length
 func (p *Player) DamagePerMin() float64 {
-	return float64(p.Damage) / p.summary.TotalLength().Minutes()
+	return p.perMinute(p.Damage)
 }
 
 func (p *Player) DamageTakenPerMin() float64 {
-	return float64(p.DamageTaken) / p.summary.TotalLength().Minutes()
+	return p.perMinute(p.DamageTaken)
 }
 
 func (p *Player) Packs() int {
@@ -221,27 +270,37 @@ func (p *Player) KD() float64 {
 }
 
 type RoundSummary struct {
-	Length    time.Duration
-	LengthRt  time.Duration
-	ScoreRed  int
-	ScoreBlu  int
-	KillsRed  int
-	KillsBlu  int
-	UbersRed  int
-	UbersBlu  int
-	DamageRed int64
-	DamageBlu int64
-	Winner    Team
-	MidFight  Team // SPEC == nobody has capped mid yet for the round
+	Length       time.Duration
+	LengthRt     time.Duration
+	ScoreRed     int
+	ScoreBlu     int
+	KillsRed     int
+	KillsBlu     int
+	UbersRed     int
+	UbersBlu     int
+	DamageRed    int64
+	DamageBlu    int64
+	Winner       Team
+	MidFight     Team          // SPEC == nobody has capped mid yet for the round
+	MidFightTime time.Duration // Game time from the round start until the midfight was won
+	Events       []RoundEvent  // Timeline of the round in order
+	Points       map[PointKey]*ControlPoint
+	Captures     []PointCapture
+	Blocks       []CaptureBlock
+	firstBlood   bool
+	lastHold     Team // Team defending its last point, SPEC when nobody is
 }
 
 type TeamSummary struct {
-	Kills     int
-	Damage    int64
-	Charges   int
-	Drops     int
-	Caps      int
-	MidFights int
+	Kills      int
+	Damage     int64
+	Charges    int
+	Drops      int
+	Caps       int
+	IntelCaps  int
+	MidFights  int
+	LastHolds  int // Times the team held its last point on 5CP
+	LastLosses int // Times the team lost the round while defending its last point
 }
 
 type Message struct {
@@ -258,23 +317,38 @@ type LogSummary struct {
 	MatchName           string
 	ServerName          string
 	Map                 string
+	Cvars               map[string]string // Cvars announced by the server, the latest value of each
+	Configs             []string          // Configs executed over rcon in the order they were run
+	TeamNames           map[Team]string   // Tournament mode team names
+	GameMode            GameMode
 	ScoreRed            int
 	ScoreBlu            int
 	Duration            time.Duration
 	CreatedOn           time.Time
 	Rounds              []*RoundSummary
 	Messages            []Message
+	Pauses              []Pause
+	Report              *ParseReport
+	Strict              bool // Return errors for unhandled lines and bad values instead of only reporting them
+	Options             ParseOptions
+	Streaks             StreakOptions
+	FinalScore          map[Team]int // Scores from the final score lines
+	CountWarmup         bool         // Record player stats before the first round starts
+	CountHumiliation    bool         // Record player stats between the round win and the next round
+	timeline            timeline
+	lineNum             int
+	lineText            string
+	modeStats           modeStats
 	roundStarted        bool
 	roundStartTime      time.Time
-	currentRound        int
+	currentRound        int // Number of the round last started, 0 before the first
 	currentRoundSummary *RoundSummary
-	lastPause           time.Time
-	lastPauseDuration   time.Duration
 	paused              bool
-}
-
-func (s *LogSummary) isRoundStarted() bool {
-	return s.roundStarted
+	phase               MatchPhase
+	phaseBeforePause    MatchPhase
+	now                 time.Time // Time of the event being applied
+	matchStart          time.Time // Start of the first round
+	events              []RoundEvent
 }
 
 func NewSummary() *LogSummary {
@@ -284,8 +358,14 @@ func NewSummary() *LogSummary {
 			RED: {},
 			BLU: {},
 		},
+		Cvars:        map[string]string{},
+		TeamNames:    map[Team]string{},
+		FinalScore:   map[Team]int{},
+		phase:        PhaseUnknown,
+		Report:       NewParseReport(),
+		modeStats:    modeStats{caps: map[Team]int{}},
 		roundStarted: false,
-		currentRound: 1,
+		Streaks:      DefaultStreakOptions(),
 	}
 }
 
@@ -305,6 +385,7 @@ func (s *LogSummary) getPlayer(steamId steamid.SID64) *Player {
 	player, found := s.Players[steamId]
 	if !found {
 		player = NewPlayer(s)
+		player.SteamId = steamId
 		s.Players[steamId] = player
 	}
 	return player
@@ -432,136 +513,172 @@ func (s *LogSummary) GetPlayersByClass(class PlayerClass) []*Player {
 	return p
 }
 
-// Apply will parse the input line and send the results to the appropriate method to apply the
-// state update.1
-//
-// NOTE We are relying on the rx engine to match group names, so we dont check most keys first
-func (s *LogSummary) Apply(line string) {
+// Apply will parse the input line and send the resulting event to ApplyEvent. Lines that fail to
+// parse are recorded in the Report. An error is only returned when Strict is enabled.
+func (s *LogSummary) Apply(line string) error {
+	s.lineNum++
 	line = strings.TrimRight(line, "\r\n")
 	if line == "" {
-		return
+		return nil
 	}
-	var (
-		player1 *Player
-		player2 *Player
-	)
-	d, msgType := parseLine(line)
-	if msgType == skipped {
+	ev, err := s.timeline.parse(line, s.Options)
+	return s.applyParsed(s.lineNum, line, ev, err)
+}
+
+// applyParsed records the outcome of parsing the line and applies the event if there is one.
+// Apply and ApplyReader share the line count so the report refers to the same line numbers
+// however the log was fed in.
+func (s *LogSummary) applyParsed(lineNum int, line string, ev Event, err error) error {
+	s.lineNum, s.lineText = lineNum, line
+	s.Report.Lines++
+	if err != nil {
+		s.Report.record(lineNum, line, err)
+		if s.Strict && err != ErrSkippedLine {
+			return &LineError{Line: lineNum, Text: line, Err: err}
+		}
+	}
+	if ev != nil {
+		// Consistency problems only end up in the report, they are expected in valid logs eg.
+		// a final score not matching when the log starts after the first round
+		s.ApplyEvent(ev)
+	}
+	return nil
+}
+
+// eventError records a problem with the event being applied against the current line
+func (s *LogSummary) eventError(err error) {
+	s.Report.recordEvent(s.lineNum, s.lineText, err)
+}
+
+// playerRef returns the player for the reference, filling in the name on first sight. The
+// console and unparsed references return nil.
+func (s *LogSummary) playerRef(ref PlayerRef) *Player {
+	player := s.getPlayer(ref.SteamID)
+	if player == nil {
+		return nil
+	}
+	if player.Name == "" {
+		player.Name = ref.Name
+		player.IsBot = ref.IsBot
+	}
+	s.seen(player)
+	return player
+}
+
+// ApplyEvent sends a parsed event to the appropriate method to apply the state update.
+func (s *LogSummary) ApplyEvent(event Event) {
+	if event == nil {
 		return
 	}
-	if msgType == unhandledMsg {
-		log.Warnf("Unhandled message: %s", line)
+	if ts := event.Timestamp(); !ts.IsZero() {
+		s.now = ts
+	}
+	defer s.tagPhase(event)
+	if isStatEvent(event) && !s.countsStats() {
 		return
 	}
-	p1SId, ok := d["sid"]
-	if ok {
-		player1 = s.getPlayer(steamid.SID3ToSID64(steamid.SID3(p1SId)))
-		if player1 != nil && player1.Name == "" {
-			player1.Name = d["name"]
-		}
-	}
-	p2SId, ok := d["sid2"]
-	if ok {
-		player2 = s.getPlayer(steamid.SID3ToSID64(steamid.SID3(p2SId)))
-		if player2 != nil && player2.Name == "" {
-			player2.Name = d["name2"]
-		}
-	}
-	dt := parseDateTime(d["date"], d["time"])
-	switch msgType {
-	case connected:
-	case validated:
-	case entered:
-	case joinedTeam:
-		s.joinTeam(player1, parseTeam(d["team"]))
-	case changeClass:
-		// Spawned as seems to be what we actually want
-		s.spawnedAs(player1, parsePlayerClass(d["class"]))
-	case spawnedAs:
-		s.spawnedAs(player1, parsePlayerClass(d["class"]))
-		s.joinTeam(player1, parseTeam(d["team"]))
-	case suicide:
-		s.suicide(player1, parsePos(d["pos"]), dt)
-	case shotFired:
-		s.shotFired(player1, d["weapon"])
-	case shotHit:
-		s.shotHit(player1, d["weapon"])
-	case damage:
-		weapon := ""
-		healing := int64(0)
-		realDamage := int64(0)
-		damage := int64(0)
-		airshot := false
-		params := parseParams(d["body"])
-		for i, p := range params {
-			var err error
-			switch p {
-			case "damage":
-				if realDamage > 0 {
-					continue
-				}
-				damage, err = strconv.ParseInt(params[i+1], 10, 64)
-				if err != nil {
-					log.Warnf("Failed to parse damage: %s", params[i+1])
-					return
-				}
-			case "realdamage":
-				// Real damage is counted for back stabs
-				realDamage, err = strconv.ParseInt(params[i+1], 10, 64)
-				if err != nil {
-					log.Warnf("Failed to parse realdamage: %s", params[i+1])
-					return
-				}
-			case "weapon":
-				weapon = params[i+1]
-			case "healing":
-				healing, err = strconv.ParseInt(params[i+1], 10, 64)
-				if err != nil {
-					log.Warnf("Failed to parse healing: %s", params[i+1])
-					return
-				}
-			case "airshot":
-				airshot = true
-			}
-		}
+	switch ev := event.(type) {
+	case *ConnectedEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.connected(p, ev.CreatedOn)
+		}
+	case *ValidatedEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.validated(p, ev.CreatedOn)
+		}
+	case *EnteredEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.entered(p, ev.CreatedOn)
+		}
+	case *DisconnectedEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.disconnected(p, ev.CreatedOn, ev.Reason)
+		}
+	case *JoinedTeamEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.joinTeam(p, ev.NewTeam)
+		}
+	case *ChangeClassEvent:
+		// Spawned as seems to be what we actually want, the player keeps playing the old class
+		// until they respawn
+		s.playerRef(ev.Player)
+	case *SpawnedAsEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.spawnedAs(p, ev.Class)
+			s.joinTeam(p, ev.Player.Team)
+		}
+	case *SuicideEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.suicide(p, ev.AttackerPos, ev.Weapon, ev.CreatedOn)
+		}
+	case *ShotFiredEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.shotFired(p, ev.Weapon)
+		}
+	case *ShotHitEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.shotHit(p, ev.Weapon)
+		}
+	case *DamageEvent:
+		player1 := s.playerRef(ev.Player)
 		if player1 == nil {
 			break
 		}
-		if isRealDamageWeapon(weapon) && realDamage > 0 {
-			s.damage(player1, realDamage, weapon, player2)
+		player2 := s.playerRef(ev.Victim)
+		if isRealDamageWeapon(ev.Weapon) && ev.RealDamage > 0 {
+			s.damage(player1, ev.RealDamage, ev.Weapon, player2)
 		} else {
-			s.damage(player1, damage, weapon, player2)
+			s.damage(player1, ev.Damage, ev.Weapon, player2)
 		}
 		// Some attacks will heal as well
-		if healing > 0 {
-			s.selfHealed(player1, healing)
+		if ev.Healing > 0 {
+			s.selfHealed(player1, ev.Healing)
+			s.healReceived(player1, HealWeapon, ev.Healing)
 		}
-		if airshot {
+		if ev.Airshot {
 			s.airShot(player1)
 		}
-	case killedCustom:
-		// TODO just move this to damage and parseAttr?
-		if d["customkill"] == "headshot" {
-			s.headShot(player1, parsePos(d["apos"]), d["weapon"], player2, parsePos(d["vpos"]), dt)
-		} else if d["customkill"] == "backstab" {
-			s.backStab(player1, parsePos(d["apos"]), d["weapon"], player2, parsePos(d["vpos"]), dt)
-		}
-	case killed:
-		s.killed(player1, parsePos(d["apos"]), d["weapon"], player2, parsePos(d["vpos"]), dt)
-	case killAssist:
-		s.assist(player1, parsePos(d["aspos"]), player2, parsePos(d["apos"]))
-	case domination:
+	case *KillEvent:
+		player1, player2 := s.playerRef(ev.Player), s.playerRef(ev.Victim)
+		if player1 == nil || player2 == nil {
+			break
+		}
+		switch ev.CustomKill {
+		case "":
+			s.killed(player1, ev.AttackerPos, ev.Weapon, player2, ev.VictimPos, ev.CreatedOn)
+		case "headshot":
+			s.headShot(player1, ev.AttackerPos, ev.Weapon, player2, ev.VictimPos, ev.CreatedOn)
+		case "backstab":
+			s.backStab(player1, ev.AttackerPos, ev.Weapon, player2, ev.VictimPos, ev.CreatedOn)
+		}
+	case *KillAssistEvent:
+		player1, player2 := s.playerRef(ev.Player), s.playerRef(ev.Victim)
+		if player1 == nil {
+			break
+		}
+		s.assist(player1, ev.AssisterPos, player2, ev.AttackerPos)
+	case *DominationEvent:
+		player1, player2 := s.playerRef(ev.Player), s.playerRef(ev.Victim)
+		if player1 == nil || player2 == nil {
+			break
+		}
 		s.domination(player1, player2)
-	case revenge:
-		s.revenge(player1)
-	case pickup:
+	case *RevengeEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.revenge(p)
+		}
+	case *PickupEvent:
+		player1 := s.playerRef(ev.Player)
 		if player1 == nil {
 			break
 		}
-		if strings.Contains(d["item"], "ammo") {
-			_ = parseAmmoPack(d["item"])
+		if ev.Healing > 0 {
+			s.itemHealed(player1, ev.Item, ev.Healing)
+		}
+		if strings.Contains(ev.Item, "ammo") {
+			_ = parseAmmoPack(ev.Item)
 		} else {
-			hp := parseHealthPack(d["item"])
+			hp := parseHealthPack(ev.Item)
 			switch hp {
 			case hpSmall:
 				player1.SmallMedPacks++
@@ -571,119 +688,159 @@ func (s *LogSummary) Apply(line string) {
 				player1.FullMedPacks += 4
 			}
 		}
-	case say:
-		s.say(player1, dt, d["msg"], false)
-	case sayTeam:
-		s.say(player1, dt, d["msg"], true)
-	case emptyUber:
-		s.emptyUber(player1, dt)
-	case medicDeath:
-		if d["uber"] == "1" {
-			s.chargeDropped(player2)
-		}
-	case medicDeathEx:
-		pct, err := strconv.ParseInt(d["pct"], 10, 64)
-		if err != nil {
-			log.Warnf("Failed to parse duration:%s", d["duration"])
+	case *SayEvent:
+		s.say(s.playerRef(ev.Player), ev.CreatedOn, ev.Message, ev.TeamChat)
+	case *EmptyUberEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.emptyUber(p, ev.CreatedOn)
+		}
+	case *MedicDeathEvent:
+		p := s.playerRef(ev.Victim)
+		if p == nil {
 			break
 		}
-		if pct > 80 {
-			s.chargeAlmostDropped(player1)
+		if ev.HadUber {
+			s.chargeDropped(p)
 		}
-	case lostUberAdv:
-		s.lostAdvantage(player1)
-	case chargeReady:
-	case chargeDeployed:
-		s.chargeDeployed(player1, parseMedigun(d["medigun"]))
-	case chargeEnded:
-		duration, err := strconv.ParseFloat(d["duration"], 64)
-		if err != nil {
-			log.Warnf("Failed to parse duration:%s", d["duration"])
-			break
+		s.medicDeath(p, ev.CreatedOn)
+		var killer steamid.SID64
+		if k := s.playerRef(ev.Player); k != nil {
+			killer = k.SteamId
 		}
-		s.chargeEnded(player1, duration)
-	case healed:
-		if player1 == nil {
-			return
+		s.addRoundEvent(RoundEvent{Type: RoundEventMedicDeath, Team: p.Team, SteamId: p.SteamId, Killer: killer})
+		if ev.HadUber {
+			s.addRoundEvent(RoundEvent{Type: RoundEventDrop, Team: p.Team, SteamId: p.SteamId, Killer: killer})
 		}
-		healing, err := strconv.ParseInt(d["healing"], 10, 64)
-		if err != nil {
-			log.Warnf("Failed to parse healing:%s", line)
-			break
+	case *MedicDeathExEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil && ev.UberPct > 80 {
+			s.chargeAlmostDropped(p)
 		}
-		if player1.CurrentClass == medic {
-			s.healed(player1, player2, healing)
-		}
-		// TODO record sandvich/other healing items
-	case extinguished:
-	case builtObject:
-	case carryObject:
-	case killedObject:
-	case detonatedObject:
-	case dropObject:
-	case firstHealAfterSpawn:
-		ht, err := strconv.ParseFloat(d["healtime"], 64)
-		if err != nil {
-			return
+	case *LostUberAdvantageEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.lostAdvantage(p, ev.Time)
 		}
-		d, err := time.ParseDuration(fmt.Sprintf("%.2fs", ht))
-		if err != nil {
-			return
+	case *ChargeReadyEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.chargeReady(p, ev.CreatedOn)
 		}
-		s.firstHealTime(player1, d)
-	case pointCaptured:
-		params := parseParams(d["body"])
-		var players []*Player
-		for i, p := range params {
-			if strings.HasPrefix(p, "player") {
-				m := rxPlayer.FindStringSubmatch(params[i+1])
-				if len(m) < 4 {
-					log.Warnf("Failed to parse SID from: %s", params[i+1])
-					return
-				}
-				players = append(players, s.getPlayer(steamid.SID3ToSID64(steamid.SID3(m[3]))))
-			} else if strings.HasPrefix(p, "position") {
-				// Useful at all?
-			}
+	case *ChargeDeployedEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.chargeDeployed(p, ev.Medigun, ev.CreatedOn)
 		}
-		pc, err := strconv.ParseInt(d["numcappers"], 10, 64)
-		if err != nil {
-			log.Warnf("Failed to parse numcappers: %s", line)
-			break
+	case *ChargeEndedEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.chargeEnded(p, ev.Duration)
 		}
-		if len(players) == 0 {
+	case *HealedEvent:
+		player1 := s.playerRef(ev.Player)
+		if player1 == nil {
 			break
 		}
-		if int64(len(players)) != pc {
-			log.Warnf("Didnt parse matching player count: %s", line)
+		player2 := s.playerRef(ev.Target)
+		if player1.CurrentClass == medic {
+			s.healed(player1, player2, ev.Healing)
+		}
+		if player2 != nil {
+			s.healedBy(player1, player2, ev.Healing)
+		}
+	case *ExtinguishedEvent:
+		player1, player2 := s.playerRef(ev.Player), s.playerRef(ev.Target)
+		if player1 == nil || player2 == nil {
 			break
 		}
+		s.extinguished(player1, player2, ev.Weapon, ev.AttackerPos, ev.VictimPos, ev.CreatedOn)
+	case *DeflectedEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.objectDeflected(p, ev.Weapon)
+		}
+	case *PlayerDeflectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.playerDeflected(p)
+		}
+	case *BuiltObjectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.builtObject(p, ev.Object)
+		}
+	case *CarryObjectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.carryObject(p)
+		}
+	case *DropObjectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.dropObject(p)
+		}
+	case *DetonatedObjectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.detonatedObject(p, ev.Object)
+		}
+	case *KilledObjectEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.killedObject(p, s.playerRef(ev.Owner), ev.Object, ev.Assist)
+		}
+	case *FirstHealAfterSpawnEvent:
+		if p := s.playerRef(ev.Player); p != nil && p.HealingSum != nil {
+			s.firstHealTime(p, ev.HealTime)
+		}
+	case *PointCapturedEvent:
+		s.countCapture(ev.Team, ev.CP, ev.CPName)
+		s.addRoundEvent(RoundEvent{Type: RoundEventPointCap, Team: ev.Team, Point: ev.CP})
+		var players []*Player
+		for _, c := range ev.Cappers {
+			if p := s.playerRef(c); p != nil {
+				players = append(players, p)
+			}
+		}
+		if len(players) > 0 && len(players) != ev.NumCappers {
+			s.eventError(fmt.Errorf("%w: %d != %d", ErrCapperCount, len(players), ev.NumCappers))
+			players = nil
+		}
 		for _, p := range players {
 			s.pointCapture(p)
 		}
-		if s.currentRoundSummary.MidFight == SPEC {
-			s.currentRoundSummary.MidFight = players[0].Team
-		}
-	case captureBlocked:
-		s.captureBlocked(player1)
-	case wRoundWin:
-		winner := parseTeam(d["winner"])
-		s.wRoundWin(dt, winner)
-	case wRoundLen:
-		dur, err := time.ParseDuration(fmt.Sprintf("%ss", d["len"]))
-		if err != nil {
-			log.Warnf("Failed to parse round len")
-			break
+		s.pointCaptured(ev.Team, ev.CP, ev.CPName, players)
+	case *FlagEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.flagEvent(p, ev.Player.Team, ev.Action, ev.CreatedOn)
+		}
+	case *CaptureBlockedEvent:
+		if p := s.playerRef(ev.Player); p != nil {
+			s.captureBlocked(p)
+			s.pointBlocked(p, ev.Player.Team, ev.CP, ev.CPName)
+		}
+	case *RoundWinEvent:
+		s.wRoundWin(ev.CreatedOn, ev.Winner)
+	case *RoundLengthEvent:
+		s.wRoundLen(ev.Length, s.gameDuration(s.roundStartTime, ev.CreatedOn))
+	case *RoundStartEvent:
+		s.wRoundStart(ev.CreatedOn)
+	case *PausedEvent:
+		s.pause(ev.CreatedOn)
+	case *UnpausedEvent:
+		s.unpause(ev.CreatedOn)
+	case *RoundOvertimeEvent:
+		s.overtime()
+	case *GameOverEvent:
+		s.gameOver()
+	case *TeamScoreEvent:
+		s.teamScore(ev.Team, ev.Score, ev.Final)
+	case *LogStartedEvent, *TournamentStartedEvent:
+		s.pregame()
+	case *LoadingMapEvent:
+		s.setMap(ev.Map)
+		s.pregame()
+	case *StartedMapEvent:
+		s.setMap(ev.Map)
+		s.pregame()
+	case *ServerCvarEvent:
+		s.serverCvar(ev.Name, ev.Value)
+	case *TeamNameEvent:
+		s.TeamNames[ev.Team] = ev.Name
+	case *RconEvent:
+		s.rconCommand(ev.Command)
+		if isTournamentRestart(ev.Command) {
+			s.pregame()
 		}
-		s.wRoundLen(dur, dt.Sub(s.roundStartTime))
-	case wRoundStart:
-		s.wRoundStart(dt)
-	case wPaused:
-		s.pause(dt)
-	case wUnpaused:
-		s.unpause(dt)
 	}
-
 }
 
 // parseLine iterates over the regex parsers and if a match is found will return a
@@ -698,9 +855,14 @@ func parseLine(logLine string) (map[string]string, MsgType) {
 	return nil, unhandledMsg
 }
 
+// LoadApiResponse fills in the match details only available from the logs.tf api. The map is
+// only replaced when the api knows it.
 func (s *LogSummary) LoadApiResponse(r *ApiResponse) error {
 	s.MatchName = r.Info.Title
-	s.Map = r.Info.Map
+	if r.Info.Map != "" {
+		s.Map = r.Info.Map
+	}
+	s.updateGameMode()
 	return nil
 }
 
@@ -714,6 +876,11 @@ func readLog(logId int64) (*LogSummary, error) {
 		if err != nil {
 			return ls, err
 		}
+		defer func() {
+			if err := zf.Close(); err != nil {
+				log.WithError(err).Errorf("Failed to close logstf zip")
+			}
+		}()
 		if len(zf.File) == 0 {
 			return ls, errors.New("no files found in zip archive")
 		}
@@ -721,46 +888,36 @@ func readLog(logId int64) (*LogSummary, error) {
 		if err != nil {
 			return ls, err
 		}
-		content, err := ioutil.ReadAll(ff)
-		if err != nil {
-			return ls, err
-		}
-		for _, line := range strings.Split(string(content), "\n") {
-			if line != "" && line != "\r" {
-				ls.Apply(line)
-			}
-		}
-	} else {
-		file, err := os.Open(rawLogPath)
-		if err != nil {
-			return ls, err
-		}
 		defer func() {
-			if err := file.Close(); err != nil {
-				log.WithError(err).Errorf("Failed to close logstf fh")
+			if err := ff.Close(); err != nil {
+				log.WithError(err).Errorf("Failed to close logstf zip entry")
 			}
 		}()
-		scanner := bufio.NewScanner(file)
-		var v string
-		for scanner.Scan() {
-			v = scanner.Text()
-			ls.Apply(v)
-		}
+		return ls, ls.ApplyReader(context.Background(), ff)
+	}
+	file, err := os.Open(rawLogPath)
+	if err != nil {
+		return ls, err
 	}
-	return ls, nil
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.WithError(err).Errorf("Failed to close logstf fh")
+		}
+	}()
+	return ls, ls.ApplyReader(context.Background(), file)
 }
 
 func ReadJSON(logId int64) (*ApiResponse, error) {
-	var ar *ApiResponse
+	var ar ApiResponse
 	rawLogPath := path.Join(cacheDir, LogCacheFile(logId, JSONFormat))
 	b, err := ioutil.ReadFile(rawLogPath)
 	if err != nil {
 		return nil, err
 	}
-	if err := json.Unmarshal(b, ar); err != nil {
+	if err := json.Unmarshal(b, &ar); err != nil {
 		return nil, err
 	}
-	return ar, nil
+	return &ar, nil
 }
 
 func Get(logId int64) (*LogSummary, error) {
@@ -768,9 +925,11 @@ func Get(logId int64) (*LogSummary, error) {
 	if err != nil {
 		return nil, err
 	}
+	// The map and server details come from the log itself, the api response only adds the title
 	ar, err := ReadJSON(logId)
 	if err != nil {
-		return nil, err
+		log.WithError(err).Warnf("No api response for log %d", logId)
+		return sum, nil
 	}
 	if err := sum.LoadApiResponse(ar); err != nil {
 		log.Warnf("Failed to read api response")
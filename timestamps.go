@@ -0,0 +1,185 @@
+package logstf
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Date layouts seen in the "L 07/10/2019 - 23:28:01:" line prefix. srcds writes the month
+// first, day first logs come from relays and tools that rewrite the prefix.
+const (
+	DateLayoutMDY = "01/02/2006"
+	DateLayoutDMY = "02/01/2006"
+)
+
+// ErrInvalidDate is returned when the line prefix is not a valid date and time
+var ErrInvalidDate = errors.New("invalid date")
+
+// ParseOptions controls how the timestamp prefix of each line is interpreted
+type ParseOptions struct {
+	// DateLayout is the time package layout of the date, eg. DateLayoutDMY. When empty the
+	// layout is detected, ambiguous dates such as 07/10/2019 are read month first.
+	DateLayout string
+	// Location is the timezone the server writes its logs in. UTC is used when nil.
+	Location *time.Location
+}
+
+func (o ParseOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+	return o.Location
+}
+
+// parseLogTime converts the date and time of the line prefix. The stock layouts are converted
+// without going through time.Parse, anything else is handed to time.ParseInLocation.
+func parseLogTime(dateStr, timeStr string, opts ParseOptions) (time.Time, error) {
+	layout := opts.DateLayout
+	if layout != "" && layout != DateLayoutMDY && layout != DateLayoutDMY {
+		t, err := time.ParseInLocation(layout+" 15:04:05", dateStr+" "+timeStr, opts.location())
+		if err != nil {
+			return time.Time{}, ErrInvalidDate
+		}
+		return t, nil
+	}
+	if len(dateStr) != 10 || len(timeStr) != 8 || dateStr[2] != '/' || dateStr[5] != '/' ||
+		timeStr[2] != ':' || timeStr[5] != ':' {
+		return time.Time{}, ErrInvalidDate
+	}
+	a, ok1 := atoi2(dateStr[0:2])
+	b, ok2 := atoi2(dateStr[3:5])
+	y1, ok3 := atoi2(dateStr[6:8])
+	y2, ok4 := atoi2(dateStr[8:10])
+	hour, ok5 := atoi2(timeStr[0:2])
+	min, ok6 := atoi2(timeStr[3:5])
+	sec, ok7 := atoi2(timeStr[6:8])
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6 && ok7) {
+		return time.Time{}, ErrInvalidDate
+	}
+	month, day := a, b
+	if layout == DateLayoutDMY || (layout == "" && a > 12) {
+		month, day = b, a
+	}
+	year := y1*100 + y2
+	if month < 1 || month > 12 || day < 1 || day > daysIn(time.Month(month), year) ||
+		hour > 23 || min > 59 || sec > 59 {
+		return time.Time{}, ErrInvalidDate
+	}
+	return time.Date(year, time.Month(month), day, hour, min, sec, 0, opts.location()), nil
+}
+
+// detectDateLayout returns the layout of the date in the line prefix when the date can only
+// be read one way, otherwise an empty string
+func detectDateLayout(line string) string {
+	if !strings.HasPrefix(line, "L ") || len(line) < 12 || line[4] != '/' {
+		return ""
+	}
+	a, ok1 := atoi2(line[2:4])
+	b, ok2 := atoi2(line[5:7])
+	switch {
+	case !ok1 || !ok2:
+		return ""
+	case a > 12 && b <= 12:
+		return DateLayoutDMY
+	case b > 12 && a <= 12:
+		return DateLayoutMDY
+	}
+	return ""
+}
+
+func atoi2(s string) (int, bool) {
+	if s[0] < '0' || s[0] > '9' || s[1] < '0' || s[1] > '9' {
+		return 0, false
+	}
+	return int(s[0]-'0')*10 + int(s[1]-'0'), true
+}
+
+func daysIn(m time.Month, year int) int {
+	return time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// timeline is the timestamp state for a single stream of lines. It remembers the date layout
+// once an unambiguous date is seen and keeps timestamps from running backwards.
+type timeline struct {
+	layout  string
+	last    time.Time
+	lastRaw time.Time // Last timestamp as parsed, before normalising
+	offset  time.Duration
+}
+
+// layoutSignal is the smallest forward jump between two month first dates that is taken as
+// the date really being day first, eg. 07/10 to 08/10 over midnight
+const layoutSignal = 27 * 24 * time.Hour
+
+// parse parses the line using the options, filling in the detected layout when none is set
+func (t *timeline) parse(line string, opts ParseOptions) (Event, error) {
+	detect := opts.DateLayout == ""
+	if detect {
+		if t.layout == "" {
+			if layout := detectDateLayout(line); layout != "" {
+				t.setLayout(layout)
+			}
+		}
+		opts.DateLayout = t.layout
+	}
+	ev, err := ParseEventWithOptions(line, opts)
+	if ev != nil {
+		ts := ev.Timestamp()
+		if detect && t.layout == "" && !t.lastRaw.IsZero() && ts.Sub(t.lastRaw) >= layoutSignal {
+			prev, ok1 := swapDayMonth(t.lastRaw)
+			cur, ok2 := swapDayMonth(ts)
+			if step := cur.Sub(prev); ok1 && ok2 && step >= 0 && step <= 24*time.Hour {
+				t.setLayout(DateLayoutDMY)
+				ts = cur
+			}
+		}
+		t.lastRaw = ts
+		ev.eventBase().CreatedOn = t.normalise(ts)
+	}
+	return ev, err
+}
+
+// setLayout fixes the layout for the rest of the stream. Ambiguous dates seen before a day first
+// layout is detected were read month first, the offset is moved so the times already handed
+// out carry on without a gap. Their dates stay as read.
+func (t *timeline) setLayout(layout string) {
+	t.layout = layout
+	if layout != DateLayoutDMY || t.lastRaw.IsZero() {
+		return
+	}
+	if prev, ok := swapDayMonth(t.lastRaw); ok {
+		t.offset += t.lastRaw.Sub(prev)
+		t.lastRaw = prev
+	}
+}
+
+// swapDayMonth reads the date the other way around, false when that is not a valid date
+func swapDayMonth(ts time.Time) (time.Time, bool) {
+	if ts.Day() > 12 || int(ts.Month()) > daysIn(time.Month(ts.Day()), ts.Year()) {
+		return time.Time{}, false
+	}
+	return time.Date(ts.Year(), time.Month(ts.Day()), int(ts.Month()), ts.Hour(), ts.Minute(), ts.Second(),
+		ts.Nanosecond(), ts.Location()), true
+}
+
+// normalise shifts ts by the corrections for any earlier backwards jumps. A jump of half a day
+// or more is the time wrapping past midnight on a server that does not advance the date, so
+// whole days are added. Shorter jumps such as the DST fall back are absorbed by holding the
+// clock until the server catches up, so durations never go negative.
+func (t *timeline) normalise(ts time.Time) time.Time {
+	ts = ts.Add(t.offset)
+	if !t.last.IsZero() && ts.Before(t.last) {
+		jump := t.last.Sub(ts)
+		if jump >= 12*time.Hour {
+			days := (jump + 24*time.Hour - 1) / (24 * time.Hour)
+			t.offset += days * 24 * time.Hour
+			ts = ts.Add(days * 24 * time.Hour)
+		} else {
+			t.offset += jump
+			ts = t.last
+		}
+	}
+	t.last = ts
+	return ts
+}
@@ -0,0 +1,117 @@
+package logstf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogTime(t *testing.T) {
+	ts, err := parseLogTime("07/10/2019", "23:50:32", ParseOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 50, 32, 0, time.UTC), ts)
+
+	ts, err = parseLogTime("25/10/2019", "23:50:32", ParseOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 10, 25, 23, 50, 32, 0, time.UTC), ts)
+
+	ts, err = parseLogTime("07/10/2019", "23:50:32", ParseOptions{DateLayout: DateLayoutDMY})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 10, 7, 23, 50, 32, 0, time.UTC), ts)
+
+	loc := time.FixedZone("EST", -5*60*60)
+	ts, err = parseLogTime("07/10/2019", "23:50:32", ParseOptions{Location: loc})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 7, 11, 4, 50, 32, 0, time.UTC), ts.UTC())
+
+	ts, err = parseLogTime("2019-07-10", "23:50:32", ParseOptions{DateLayout: "2006-01-02"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2019, 7, 10, 23, 50, 32, 0, time.UTC), ts)
+
+	for _, bad := range [][2]string{{"13/13/2019", "23:50:32"}, {"02/30/2019", "23:50:32"},
+		{"07/10/2019", "24:00:00"}, {"07-10-2019", "23:50:32"}, {"7/10/2019", "23:50:32"}} {
+		_, err = parseLogTime(bad[0], bad[1], ParseOptions{})
+		assert.Equal(t, ErrInvalidDate, err, bad)
+	}
+}
+
+func TestParseEventInvalidDate(t *testing.T) {
+	line := `L 07/40/2019 - 23:28:00: World triggered "Round_Start"`
+	for _, parse := range []func(string) (Event, error){ParseEvent, parseEventRx} {
+		ev, err := parse(line)
+		assert.Nil(t, ev)
+		fe, ok := err.(*FieldError)
+		require.True(t, ok)
+		assert.Equal(t, "date", fe.Field)
+	}
+}
+
+func TestDetectDateLayout(t *testing.T) {
+	assert.Equal(t, DateLayoutDMY, detectDateLayout(`L 25/10/2019 - 23:28:00: World triggered "Round_Start"`))
+	assert.Equal(t, DateLayoutMDY, detectDateLayout(`L 10/25/2019 - 23:28:00: World triggered "Round_Start"`))
+	assert.Equal(t, "", detectDateLayout(`L 07/10/2019 - 23:28:00: World triggered "Round_Start"`))
+	assert.Equal(t, "", detectDateLayout(`garbage`))
+}
+
+func TestTimelineNormalise(t *testing.T) {
+	var tl timeline
+	base := time.Date(2019, 7, 10, 23, 59, 58, 0, time.UTC)
+	assert.Equal(t, base, tl.normalise(base))
+	// Time wrapped without the date changing
+	wrapped := time.Date(2019, 7, 10, 0, 0, 3, 0, time.UTC)
+	assert.Equal(t, base.Add(5*time.Second), tl.normalise(wrapped))
+	assert.Equal(t, base.Add(10*time.Second), tl.normalise(wrapped.Add(5*time.Second)))
+
+	// DST fall back holds the clock until it catches up
+	tl = timeline{}
+	base = time.Date(2019, 10, 27, 2, 59, 50, 0, time.UTC)
+	tl.normalise(base)
+	assert.Equal(t, base, tl.normalise(base.Add(-time.Hour+5*time.Second)))
+	assert.Equal(t, base.Add(5*time.Second), tl.normalise(base.Add(-time.Hour+10*time.Second)))
+}
+
+func TestParserMidnight(t *testing.T) {
+	input := strings.Join([]string{
+		`L 25/10/2019 - 23:59:50: World triggered "Round_Start"`,
+		`L 25/10/2019 - 00:00:10: World triggered "Round_Win" (winner "Red")`,
+	}, "\n")
+	p := NewParserWithOptions(context.Background(), strings.NewReader(input), ParseOptions{})
+	require.True(t, p.Next())
+	start := p.Event().Timestamp()
+	assert.Equal(t, time.Date(2019, 10, 25, 23, 59, 50, 0, time.UTC), start)
+	require.True(t, p.Next())
+	assert.Equal(t, 20*time.Second, p.Event().Timestamp().Sub(start))
+}
+
+func TestSummaryCrossesMidnight(t *testing.T) {
+	s := NewSummary()
+	require.NoError(t, s.Apply(`L 07/10/2019 - 23:59:30: World triggered "Round_Start"`))
+	require.NoError(t, s.Apply(`L 07/11/2019 - 00:00:30: World triggered "Round_Win" (winner "Red")`))
+	require.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, time.Minute, s.Rounds[0].LengthRt)
+}
+
+func TestSummaryCrossesMidnightDayFirst(t *testing.T) {
+	// 7th to 8th of October, both dates can be read month first
+	s := NewSummary()
+	require.NoError(t, s.Apply(`L 07/10/2019 - 23:59:30: World triggered "Round_Start"`))
+	require.NoError(t, s.Apply(`L 08/10/2019 - 00:00:30: World triggered "Round_Win" (winner "Red")`))
+	require.NoError(t, s.Apply(`L 08/10/2019 - 00:00:40: World triggered "Round_Start"`))
+	require.NoError(t, s.Apply(`L 08/10/2019 - 00:01:40: World triggered "Round_Win" (winner "Red")`))
+	require.Equal(t, 2, len(s.Rounds))
+	assert.Equal(t, time.Minute, s.Rounds[0].LengthRt)
+	assert.Equal(t, time.Minute, s.Rounds[1].LengthRt)
+	assert.Equal(t, DateLayoutDMY, s.timeline.layout)
+
+	// A date over 12 after ambiguous dates carries on without a gap
+	var tl timeline
+	ev, err := tl.parse(`L 12/10/2019 - 23:59:30: World triggered "Round_Start"`, ParseOptions{})
+	require.NoError(t, err)
+	start := ev.Timestamp()
+	ev, err = tl.parse(`L 13/10/2019 - 00:00:30: World triggered "Round_Win" (winner "Red")`, ParseOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, ev.Timestamp().Sub(start))
+}
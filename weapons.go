@@ -0,0 +1,46 @@
+package logstf
+
+// Accuracy returns the ratio of shots that hit, only weapons reported by supstats have shots
+func (w *weaponStats) Accuracy() float64 {
+	if w.Shots == 0 {
+		return 0
+	}
+	return float64(w.Hits) / float64(w.Shots)
+}
+
+// addDamage adds a single damage event, AvgDmg is the average damage per damage event the
+// same as logs.tf
+func (w *weaponStats) addDamage(amount int64) {
+	w.Dmg += int(amount)
+	w.damageHits++
+	w.AvgDmg = float64(w.Dmg) / float64(w.damageHits)
+}
+
+// merge adds the stats from the api which are split per class
+func (w *weaponStats) merge(o weaponStats) {
+	hits := w.damageHits
+	if o.AvgDmg > 0 {
+		hits += int(float64(o.Dmg)/o.AvgDmg + 0.5)
+	}
+	w.Kills += o.Kills
+	w.Dmg += o.Dmg
+	w.Shots += o.Shots
+	w.Hits += o.Hits
+	w.damageHits = hits
+	if hits > 0 {
+		w.AvgDmg = float64(w.Dmg) / float64(hits)
+	}
+}
+
+// weapon returns the stats for the weapon, nil for events without a weapon
+func (p *Player) weapon(name string) *weaponStats {
+	if name == "" {
+		return nil
+	}
+	w, found := p.Weapons[name]
+	if !found {
+		w = &weaponStats{}
+		p.Weapons[name] = w
+	}
+	return w
+}
@@ -0,0 +1,41 @@
+package logstf
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWeaponStats(t *testing.T) {
+	s := applyLines(t, []string{
+		`L 07/10/2019 - 23:00:00: World triggered "Round_Start"`,
+		`L 07/10/2019 - 23:00:01: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:01: "rad<6><[U:1:57823119]><Red>" triggered "shot_hit" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:02: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:03: "rad<6><[U:1:57823119]><Red>" triggered "shot_fired" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:03: "rad<6><[U:1:57823119]><Red>" triggered "shot_hit" (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:03: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "35") (weapon "quake_rl")`,
+		`L 07/10/2019 - 23:00:03: "rad<6><[U:1:57823119]><Red>" killed "z/<14><[U:1:66656848]><Blue>" with "quake_rl" (attacker_position "1 2 3") (victim_position "4 5 6")`,
+		`L 07/10/2019 - 23:00:04: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "20") (weapon "shotgun_soldier")`,
+	})
+	rad := s.Players[76561198018088847]
+	require.NotNil(t, rad)
+	require.Equal(t, 2, len(rad.Weapons))
+	rl := rad.Weapons["quake_rl"]
+	assert.Equal(t, 1, rl.Kills)
+	assert.Equal(t, 125, rl.Dmg)
+	assert.Equal(t, 62.5, rl.AvgDmg)
+	assert.Equal(t, 3, rl.Shots)
+	assert.Equal(t, 2, rl.Hits)
+	assert.InDelta(t, 0.666, rl.Accuracy(), 0.001)
+	assert.Equal(t, 0.0, rad.Weapons["shotgun_soldier"].Accuracy())
+	require.Equal(t, 1, len(rad.Kills))
+	assert.Equal(t, "quake_rl", rad.Kills[0].Weapon)
+	assert.Equal(t, "quake_rl", s.Players[76561198026922576].Deaths[0].Weapon)
+
+	w := &weaponStats{}
+	w.merge(weaponStats{Kills: 1, Dmg: 100, AvgDmg: 50, Shots: 4, Hits: 2})
+	w.merge(weaponStats{Kills: 2, Dmg: 200, AvgDmg: 100, Shots: 4, Hits: 2})
+	assert.Equal(t, weaponStats{Kills: 3, Dmg: 300, AvgDmg: 75, Shots: 8, Hits: 4, damageHits: 4}, *w)
+}
@@ -0,0 +1,538 @@
+package logstf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedEvent is returned when formatting an event type that has no line format
+var ErrUnsupportedEvent = errors.New("unsupported event")
+
+// FormatEvent returns the srcds log line for the event without a trailing newline. Parsing the
+// line again returns an equal event.
+//
+// Properties the event has no field for, such as those added by plugins, are written in their
+// original order. Properties that are backed by a field are always written from the field so
+// changes to the event are reflected in the line. Events built by hand get the properties in
+// the order srcds and SupStats2 write them. SteamIDs are always written in the Steam3 format.
+func FormatEvent(ev Event) (string, error) {
+	return FormatEventWithOptions(ev, ParseOptions{})
+}
+
+// FormatEventWithOptions is FormatEvent writing the timestamp in the layout and timezone of opts.
+// The month first layout is used when no layout is set.
+func FormatEventWithOptions(ev Event, opts ParseOptions) (string, error) {
+	if ev == nil {
+		return "", ErrUnsupportedEvent
+	}
+	var b strings.Builder
+	layout := opts.DateLayout
+	if layout == "" {
+		layout = DateLayoutMDY
+	}
+	b.WriteString("L ")
+	b.WriteString(ev.Timestamp().In(opts.location()).Format(layout + " - 15:04:05"))
+	b.WriteString(": ")
+	var lp lineProps
+	if err := formatBody(&b, &lp, ev); err != nil {
+		return "", err
+	}
+	for _, prop := range lp.merge(ev.eventBase().Properties) {
+		b.WriteString(" (")
+		b.WriteString(prop.Key)
+		b.WriteString(` "`)
+		b.WriteString(prop.Value)
+		b.WriteString(`")`)
+	}
+	return b.String(), nil
+}
+
+// lineProps are the properties generated from the fields of an event
+type lineProps struct {
+	items Properties
+	owned []string
+}
+
+// set adds a property generated from a field
+func (lp *lineProps) set(key, value string) {
+	lp.items = append(lp.items, Property{Key: key, Value: value})
+	lp.owned = append(lp.owned, key)
+}
+
+// setPos adds a position property, positions are always written by the server even when zero
+func (lp *lineProps) setPos(key string, pos Position) {
+	lp.set(key, fmt.Sprintf("%d %d %d", pos.X, pos.Y, pos.Z))
+}
+
+// drop marks a key as backed by a field that is currently unset, so it is not copied from
+// the original properties
+func (lp *lineProps) drop(key string) {
+	lp.owned = append(lp.owned, key)
+}
+
+func (lp *lineProps) isOwned(key string) bool {
+	for _, k := range lp.owned {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// merge returns the original properties with the field backed values replaced, followed by
+// any generated properties the original did not have
+func (lp *lineProps) merge(original Properties) Properties {
+	if len(original) == 0 {
+		return lp.items
+	}
+	written := make([]bool, len(lp.items))
+	var out Properties
+	for _, prop := range original {
+		if !lp.isOwned(prop.Key) {
+			out = append(out, prop)
+			continue
+		}
+		for i, gen := range lp.items {
+			if !written[i] && gen.Key == prop.Key {
+				out = append(out, gen)
+				written[i] = true
+				break
+			}
+		}
+	}
+	for i, gen := range lp.items {
+		if !written[i] {
+			out = append(out, gen)
+		}
+	}
+	return out
+}
+
+// formatPlayer writes the quoted player token. Unassigned players are written with an empty
+// team on the lines sent before a team is joined.
+func formatPlayer(b *strings.Builder, ref PlayerRef, beforeJoin bool) {
+	b.WriteByte('"')
+	b.WriteString(ref.Name)
+	b.WriteByte('<')
+	b.WriteString(strconv.Itoa(ref.PID))
+	b.WriteString("><")
+	switch {
+	case ref.IsConsole:
+		b.WriteString("Console")
+	case ref.IsBot:
+		b.WriteString("BOT")
+	default:
+		b.WriteString("[U:1:")
+		b.WriteString(strconv.FormatUint(uint64(ref.SteamID)-sid64Base, 10))
+		b.WriteByte(']')
+	}
+	b.WriteString("><")
+	switch {
+	case ref.IsConsole:
+		b.WriteString("Console")
+	case ref.Team == SPEC && beforeJoin:
+	default:
+		b.WriteString(teamName(ref.Team, "Unassigned"))
+	}
+	b.WriteString(`>"`)
+}
+
+// teamName returns the name the server uses for the team, spec is used for SPEC since the
+// server has both Unassigned and Spectator
+func teamName(t Team, spec string) string {
+	switch t {
+	case RED:
+		return "Red"
+	case BLU:
+		return "Blue"
+	default:
+		return spec
+	}
+}
+
+func formatQuoted(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	b.WriteString(s)
+	b.WriteByte('"')
+}
+
+// formatTriggered writes `"player" triggered "name"` along with the optional against player
+func formatTriggered(b *strings.Builder, player PlayerRef, name string, against *PlayerRef) {
+	formatPlayer(b, player, false)
+	b.WriteString(" triggered ")
+	formatQuoted(b, name)
+	if against != nil {
+		b.WriteString(" against ")
+		formatPlayer(b, *against, false)
+	}
+}
+
+func formatBool(lp *lineProps, key string, value bool) {
+	if value {
+		lp.set(key, "1")
+	} else {
+		lp.drop(key)
+	}
+}
+
+func formatBody(b *strings.Builder, lp *lineProps, event Event) error {
+	switch ev := event.(type) {
+	case *ConnectedEvent:
+		formatPlayer(b, ev.Player, true)
+		b.WriteString(" connected, address ")
+		formatQuoted(b, ev.Address)
+	case *DisconnectedEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" disconnected")
+		lp.set("reason", ev.Reason)
+	case *ValidatedEvent:
+		formatPlayer(b, ev.Player, true)
+		b.WriteString(" STEAM USERID validated")
+	case *EnteredEvent:
+		formatPlayer(b, ev.Player, true)
+		b.WriteString(" entered the game")
+	case *JoinedTeamEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" joined team ")
+		formatQuoted(b, teamName(ev.NewTeam, "Spectator"))
+	case *ChangeClassEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" changed role to ")
+		formatQuoted(b, strings.ToLower(classLogName(ev.Class)))
+	case *SpawnedAsEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" spawned as ")
+		formatQuoted(b, classLogName(ev.Class))
+	case *SuicideEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" committed suicide with ")
+		weapon := ev.Weapon
+		if weapon == "" {
+			weapon = "world"
+		}
+		formatQuoted(b, weapon)
+		lp.setPos("attacker_position", ev.AttackerPos)
+	case *ShotFiredEvent:
+		formatTriggered(b, ev.Player, "shot_fired", nil)
+		lp.set("weapon", ev.Weapon)
+	case *ShotHitEvent:
+		formatTriggered(b, ev.Player, "shot_hit", nil)
+		lp.set("weapon", ev.Weapon)
+	case *DamageEvent:
+		var victim *PlayerRef
+		if ev.Victim.SteamID.Valid() || ev.Victim.IsBot {
+			victim = &ev.Victim
+		}
+		formatTriggered(b, ev.Player, "damage", victim)
+		lp.set("damage", strconv.FormatInt(ev.Damage, 10))
+		if ev.RealDamage != 0 {
+			lp.set("realdamage", strconv.FormatInt(ev.RealDamage, 10))
+		} else {
+			lp.drop("realdamage")
+		}
+		lp.set("weapon", ev.Weapon)
+		if ev.Healing != 0 {
+			lp.set("healing", strconv.FormatInt(ev.Healing, 10))
+		} else {
+			lp.drop("healing")
+		}
+		if ev.Crit != "" {
+			lp.set("crit", ev.Crit)
+		} else {
+			lp.drop("crit")
+		}
+		formatBool(lp, "headshot", ev.Headshot)
+		formatBool(lp, "airshot", ev.Airshot)
+	case *KillEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" killed ")
+		formatPlayer(b, ev.Victim, false)
+		b.WriteString(" with ")
+		formatQuoted(b, ev.Weapon)
+		if ev.CustomKill != "" {
+			lp.set("customkill", ev.CustomKill)
+		} else {
+			lp.drop("customkill")
+		}
+		lp.setPos("attacker_position", ev.AttackerPos)
+		lp.setPos("victim_position", ev.VictimPos)
+	case *KillAssistEvent:
+		formatTriggered(b, ev.Player, "kill assist", &ev.Victim)
+		lp.setPos("assister_position", ev.AssisterPos)
+		lp.setPos("attacker_position", ev.AttackerPos)
+		lp.setPos("victim_position", ev.VictimPos)
+	case *DominationEvent:
+		formatTriggered(b, ev.Player, "domination", &ev.Victim)
+	case *RevengeEvent:
+		formatTriggered(b, ev.Player, "revenge", &ev.Victim)
+		formatBool(lp, "assist", ev.Assist)
+	case *PickupEvent:
+		formatPlayer(b, ev.Player, false)
+		b.WriteString(" picked up item ")
+		formatQuoted(b, ev.Item)
+		if ev.Healing != 0 {
+			lp.set("healing", strconv.FormatInt(ev.Healing, 10))
+		} else {
+			lp.drop("healing")
+		}
+	case *SayEvent:
+		formatPlayer(b, ev.Player, false)
+		if ev.TeamChat {
+			b.WriteString(" say_team ")
+		} else {
+			b.WriteString(" say ")
+		}
+		formatQuoted(b, ev.Message)
+	case *EmptyUberEvent:
+		formatTriggered(b, ev.Player, "empty_uber", nil)
+	case *MedicDeathEvent:
+		formatTriggered(b, ev.Player, "medic_death", &ev.Victim)
+		lp.set("healing", strconv.FormatInt(ev.Healing, 10))
+		if ev.HadUber {
+			lp.set("ubercharge", "1")
+		} else {
+			lp.set("ubercharge", "0")
+		}
+	case *MedicDeathExEvent:
+		formatTriggered(b, ev.Player, "medic_death_ex", nil)
+		lp.set("uberpct", strconv.FormatInt(ev.UberPct, 10))
+	case *LostUberAdvantageEvent:
+		formatTriggered(b, ev.Player, "lost_uber_advantage", nil)
+		lp.set("time", strconv.FormatInt(ev.Time, 10))
+	case *ChargeReadyEvent:
+		formatTriggered(b, ev.Player, "chargeready", nil)
+	case *ChargeDeployedEvent:
+		formatTriggered(b, ev.Player, "chargedeployed", nil)
+		lp.set("medigun", medigunLogName(ev.Medigun))
+	case *ChargeEndedEvent:
+		formatTriggered(b, ev.Player, "chargeended", nil)
+		lp.set("duration", strconv.FormatFloat(ev.Duration, 'f', 1, 64))
+	case *HealedEvent:
+		formatTriggered(b, ev.Player, "healed", &ev.Target)
+		lp.set("healing", strconv.FormatInt(ev.Healing, 10))
+	case *ExtinguishedEvent:
+		formatTriggered(b, ev.Player, "player_extinguished", &ev.Target)
+		b.WriteString(" with ")
+		formatQuoted(b, ev.Weapon)
+		lp.setPos("attacker_position", ev.AttackerPos)
+		lp.setPos("victim_position", ev.VictimPos)
+	case *DeflectedEvent:
+		formatTriggered(b, ev.Player, "object_deflected", nil)
+		lp.set("weapon", ev.Weapon)
+		var owner strings.Builder
+		formatPlayer(&owner, ev.Owner, false)
+		lp.set("owner", strings.Trim(owner.String(), `"`))
+		lp.setPos("object_position", ev.Position)
+	case *PlayerDeflectEvent:
+		formatTriggered(b, ev.Player, "player_deflect", &ev.Victim)
+		if ev.Weapon != "" {
+			lp.set("weapon", ev.Weapon)
+		} else {
+			lp.drop("weapon")
+		}
+		lp.setPos("attacker_position", ev.AttackerPos)
+		lp.setPos("victim_position", ev.VictimPos)
+	case *BuiltObjectEvent:
+		formatObject(b, lp, ev.Player, "player_builtobject", ev.Object, ev.Position)
+	case *CarryObjectEvent:
+		formatObject(b, lp, ev.Player, "player_carryobject", ev.Object, ev.Position)
+	case *DropObjectEvent:
+		formatObject(b, lp, ev.Player, "player_dropobject", ev.Object, ev.Position)
+	case *DetonatedObjectEvent:
+		formatObject(b, lp, ev.Player, "object_detonated", ev.Object, ev.Position)
+	case *KilledObjectEvent:
+		formatTriggered(b, ev.Player, "killedobject", nil)
+		lp.set("object", ev.Object)
+		if ev.Weapon != "" {
+			lp.set("weapon", ev.Weapon)
+		} else {
+			lp.drop("weapon")
+		}
+		var owner strings.Builder
+		formatPlayer(&owner, ev.Owner, false)
+		lp.set("objectowner", strings.Trim(owner.String(), `"`))
+		if ev.Assist {
+			lp.set("assist", "1")
+			lp.setPos("assister_position", ev.AssisterPos)
+		} else {
+			lp.drop("assist")
+			lp.drop("assister_position")
+		}
+		lp.setPos("attacker_position", ev.AttackerPos)
+	case *FirstHealAfterSpawnEvent:
+		formatTriggered(b, ev.Player, "first_heal_after_spawn", nil)
+		lp.set("time", strconv.FormatFloat(ev.HealTime.Seconds(), 'f', 1, 64))
+	case *CaptureBlockedEvent:
+		formatTriggered(b, ev.Player, "captureblocked", nil)
+		lp.set("cp", strconv.Itoa(ev.CP))
+		lp.set("cpname", ev.CPName)
+		lp.setPos("position", ev.Position)
+	case *FlagEvent:
+		formatTriggered(b, ev.Player, "flagevent", nil)
+		lp.set("event", ev.Action)
+		lp.setPos("position", ev.Position)
+	case *PointCapturedEvent:
+		b.WriteString(`Team "`)
+		b.WriteString(teamName(ev.Team, "Unassigned"))
+		b.WriteString(`" triggered "pointcaptured"`)
+		lp.set("cp", strconv.Itoa(ev.CP))
+		lp.set("cpname", ev.CPName)
+		lp.set("numcappers", strconv.Itoa(ev.NumCappers))
+		for _, prop := range ev.Properties {
+			if strings.HasPrefix(prop.Key, "player") || strings.HasPrefix(prop.Key, "position") {
+				lp.drop(prop.Key)
+			}
+		}
+		for i, capper := range ev.Cappers {
+			var player strings.Builder
+			formatPlayer(&player, capper, false)
+			lp.set(fmt.Sprintf("player%d", i+1), strings.Trim(player.String(), `"`))
+			if i < len(ev.Positions) {
+				lp.setPos(fmt.Sprintf("position%d", i+1), ev.Positions[i])
+			}
+		}
+	case *TeamScoreEvent:
+		b.WriteString(`Team "`)
+		b.WriteString(teamName(ev.Team, "Unassigned"))
+		if ev.Final {
+			b.WriteString(`" final score "`)
+		} else {
+			b.WriteString(`" current score "`)
+		}
+		b.WriteString(strconv.Itoa(ev.Score))
+		b.WriteString(`" with "`)
+		b.WriteString(strconv.Itoa(ev.Players))
+		b.WriteString(`" players`)
+	case *RoundStartEvent:
+		b.WriteString(`World triggered "Round_Start"`)
+	case *RoundOvertimeEvent:
+		b.WriteString(`World triggered "Round_Overtime"`)
+	case *RoundWinEvent:
+		b.WriteString(`World triggered "Round_Win"`)
+		lp.set("winner", teamName(ev.Winner, "Unassigned"))
+	case *RoundLengthEvent:
+		b.WriteString(`World triggered "Round_Length"`)
+		lp.set("seconds", strconv.FormatFloat(ev.Length.Seconds(), 'f', 2, 64))
+	case *GameOverEvent:
+		b.WriteString(`World triggered "Game_Over" reason `)
+		formatQuoted(b, ev.Reason)
+	case *PausedEvent:
+		b.WriteString(`World triggered "Game_Paused"`)
+	case *UnpausedEvent:
+		b.WriteString(`World triggered "Game_Unpaused"`)
+	case *LogStartedEvent:
+		b.WriteString("Log file started")
+		lp.set("file", ev.File)
+		lp.set("game", ev.Game)
+		lp.set("version", ev.Version)
+	case *LoadingMapEvent:
+		b.WriteString("Loading map ")
+		formatQuoted(b, ev.Map)
+	case *StartedMapEvent:
+		b.WriteString("Started map ")
+		formatQuoted(b, ev.Map)
+	case *ServerCvarEvent:
+		if ev.Listing {
+			formatQuoted(b, ev.Name)
+			b.WriteString(" = ")
+		} else {
+			b.WriteString("server_cvar: ")
+			formatQuoted(b, ev.Name)
+			b.WriteByte(' ')
+		}
+		formatQuoted(b, ev.Value)
+	case *TournamentStartedEvent:
+		b.WriteString("Tournament mode started")
+	case *TeamNameEvent:
+		b.WriteString(teamName(ev.Team, "Unassigned"))
+		b.WriteString(" Team: ")
+		b.WriteString(ev.Name)
+	case *RconEvent:
+		b.WriteString("rcon from ")
+		formatQuoted(b, ev.Address)
+		b.WriteString(": command ")
+		formatQuoted(b, ev.Command)
+	default:
+		return ErrUnsupportedEvent
+	}
+	return nil
+}
+
+func formatObject(b *strings.Builder, lp *lineProps, player PlayerRef, name string, object string, pos Position) {
+	formatTriggered(b, player, name, nil)
+	lp.set("object", object)
+	lp.setPos("position", pos)
+}
+
+// classLogName returns the class name as written in "spawned as" lines
+func classLogName(cls PlayerClass) string {
+	switch cls {
+	case scout:
+		return "Scout"
+	case soldier:
+		return "Soldier"
+	case pyro:
+		return "Pyro"
+	case demo:
+		return "Demoman"
+	case heavy:
+		return "HeavyWeapons"
+	case engineer:
+		return "Engineer"
+	case medic:
+		return "Medic"
+	case sniper:
+		return "Sniper"
+	case spy:
+		return "Spy"
+	default:
+		return "undefined"
+	}
+}
+
+func medigunLogName(medigun Medigun) string {
+	switch medigun {
+	case kritzkrieg:
+		return "kritzkrieg"
+	case vaccinator:
+		return "vaccinator"
+	case quickFix:
+		return "quickfix"
+	default:
+		return "medigun"
+	}
+}
+
+// Writer writes events as log lines, it is the counterpart to Parser
+type Writer struct {
+	w    *bufio.Writer
+	opts ParseOptions
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return NewWriterWithOptions(w, ParseOptions{})
+}
+
+// NewWriterWithOptions creates a Writer that writes timestamps using the layout and timezone of opts
+func NewWriterWithOptions(w io.Writer, opts ParseOptions) *Writer {
+	return &Writer{w: bufio.NewWriter(w), opts: opts}
+}
+
+// WriteEvent writes the event as a single line
+func (w *Writer) WriteEvent(ev Event) error {
+	line, err := FormatEventWithOptions(ev, w.opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.WriteString(line + "\n")
+	return err
+}
+
+// Flush writes any buffered lines to the underlying writer
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
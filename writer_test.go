@@ -0,0 +1,109 @@
+package logstf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatEventRoundTrip(t *testing.T) {
+	extra := []string{
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" picked up item "medkit_small" (healing "20")`,
+		`L 07/10/2019 - 23:28:02: "Bot01<3><BOT><Blue>" triggered "damage" against "rad<6><[U:1:57823119]><Red>" (damage "33") (weapon "tf_projectile_rocket") (crit "mini")`,
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Red>" triggered "killedobject" (object "OBJ_DISPENSER") (objectowner "Bot01<3><BOT><Blue>") (assist "1") (assister_position "1 2 3") (attacker_position "4 5 6")`,
+		`L 07/10/2019 - 23:28:02: "Console<0><Console><Console>" say "hello"`,
+		`L 07/10/2019 - 23:28:02: "rad<6><[U:1:57823119]><Spectator>" joined team "Spectator"`,
+	}
+	for _, line := range append(append([]string{}, testMatchLines...), extra...) {
+		ev, err := ParseEvent(line)
+		if errors.Is(err, ErrSkippedLine) {
+			continue
+		}
+		require.NoError(t, err, line)
+		out, err := FormatEvent(ev)
+		require.NoError(t, err)
+		if _, ok := ev.(*JoinedTeamEvent); ok && strings.Contains(line, "Spectator>") {
+			// Unassigned and Spectator are both SPEC so only the event is compared
+			line = strings.Replace(line, "<Spectator>", "<Unassigned>", 1)
+		}
+		assert.Equal(t, line, out)
+		// The regex parser never matched the console as a player
+		if !strings.Contains(line, "<Console>") {
+			evRx, err := parseEventRx(out)
+			require.NoError(t, err, line)
+			assert.Equal(t, ev.MsgType(), evRx.MsgType(), line)
+		}
+		again, err := ParseEvent(out)
+		require.NoError(t, err)
+		assert.Equal(t, ev, again)
+	}
+}
+
+func TestFormatEventFields(t *testing.T) {
+	ev, err := ParseEvent(`L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "90") (weapon "quake_rl") (airshot "1") (custom "x")`)
+	require.NoError(t, err)
+	dmg := ev.(*DamageEvent)
+	dmg.Damage = 45
+	dmg.Airshot = false
+	dmg.Healing = 10
+	out, err := FormatEvent(dmg)
+	require.NoError(t, err)
+	assert.Equal(t, `L 07/10/2019 - 23:28:01: "rad<6><[U:1:57823119]><Red>" triggered "damage" against "z/<14><[U:1:66656848]><Blue>" (damage "45") (weapon "quake_rl") (custom "x") (healing "10")`, out)
+
+	loc := time.FixedZone("CET", 60*60)
+	out, err = FormatEventWithOptions(&RoundStartEvent{EventBase: EventBase{CreatedOn: time.Date(2019, 10, 25, 22, 0, 0, 0, time.UTC)}},
+		ParseOptions{DateLayout: DateLayoutDMY, Location: loc})
+	require.NoError(t, err)
+	assert.Equal(t, `L 25/10/2019 - 23:00:00: World triggered "Round_Start"`, out)
+
+	_, err = FormatEvent(nil)
+	assert.Equal(t, ErrUnsupportedEvent, err)
+}
+
+// TestWriterFixture builds a short scenario with a drop, a midfight and a pause from events
+func TestWriterFixture(t *testing.T) {
+	start := time.Date(2019, 7, 10, 23, 28, 0, 0, time.UTC)
+	at := func(sec int) EventBase {
+		return EventBase{CreatedOn: start.Add(time.Duration(sec) * time.Second)}
+	}
+	attacker := PlayerRef{Name: "soldier", PID: 2, SteamID: sid64Base + 100, Team: RED}
+	healer := PlayerRef{Name: "medic", PID: 3, SteamID: sid64Base + 200, Team: BLU}
+	events := []Event{
+		&RoundStartEvent{EventBase: at(0)},
+		&SpawnedAsEvent{EventBase: at(0), Player: attacker, Class: soldier},
+		&SpawnedAsEvent{EventBase: at(0), Player: healer, Class: medic},
+		&DamageEvent{EventBase: at(5), Player: attacker, Victim: healer, Damage: 120, Weapon: "quake_rl", Airshot: true},
+		&MedicDeathEvent{EventBase: at(6), Player: attacker, Victim: healer, Healing: 300, HadUber: true},
+		&KillEvent{EventBase: at(6), Player: attacker, Victim: healer, Weapon: "quake_rl"},
+		&PointCapturedEvent{EventBase: at(20), Team: RED, CP: 2, CPName: "#cp_mid", NumCappers: 1,
+			Cappers: []PlayerRef{attacker}, Positions: []Position{{1, 2, 3}}},
+		&PausedEvent{EventBase: at(30)},
+		&UnpausedEvent{EventBase: at(90)},
+		&RoundWinEvent{EventBase: at(100), Winner: RED},
+		&RoundLengthEvent{EventBase: at(100), Length: 40 * time.Second},
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, ev := range events {
+		require.NoError(t, w.WriteEvent(ev))
+	}
+	require.NoError(t, w.Flush())
+
+	s := NewSummary()
+	s.Strict = true
+	require.NoError(t, s.ApplyReader(context.Background(), &buf))
+	require.Equal(t, 1, len(s.Rounds))
+	assert.Equal(t, RED, s.Rounds[0].MidFight)
+	assert.Equal(t, 1, s.ScoreRed)
+	assert.Equal(t, 1, s.Players[healer.SteamID].HealingSum.Drops)
+	assert.Equal(t, int64(120), s.Players[attacker.SteamID].Damage)
+	assert.Equal(t, 1, s.Players[attacker.SteamID].AirShots)
+	require.Equal(t, 1, len(s.Pauses))
+	assert.Equal(t, time.Minute, s.PausedTime())
+	assert.Equal(t, 40*time.Second, s.Rounds[0].LengthRt)
+}